@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/model"
+	"strings"
+)
+
+var blindModeNames = map[model.BlindMode]string{
+	model.NotBlind:    "none",
+	model.SingleBlind: "single",
+	model.DoubleBlind: "double",
+}
+
+// decodeAdminRequest decodes the request body and checks that it comes from an
+// admin. The caller must hold model.Mutex. On failure an error has already been
+// written to w and ok is false.
+func decodeAdminRequest(w http.ResponseWriter, r *http.Request) (received map[string]interface{}, userId int32, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return nil, 0, false
+	}
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return nil, 0, false
+	}
+	if !model.IsAdmin(userId) {
+		httpError(w, r, http.StatusForbidden, "Forbidden: admin only")
+		return nil, 0, false
+	}
+	return received, userId, true
+}
+
+func writeRoundSettings(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ReviewsPerUser":       model.Settings.ReviewsPerUser,
+		"RestrictToAssignment": model.Settings.RestrictToAssignment,
+		"BlindMode":            blindModeNames[model.Settings.Blind],
+		"Rubric":               model.Settings.Rubric,
+		"MinReviews":           model.Settings.MinReviews,
+		"MinReviewLength":      model.Settings.MinReviewLength,
+		"QuotaPenalty":         model.Settings.QuotaPenalty,
+		"HelpfulBonus":         model.Settings.HelpfulBonus,
+		"SimilarityThreshold":  model.Settings.SimilarityThreshold,
+		"MaxFiles":             model.Settings.Submission.MaxFiles,
+		"MaxFileBytes":         model.Settings.Submission.MaxFileBytes,
+		"MaxTotalBytes":        model.Settings.Submission.MaxTotalBytes,
+		"MaxVersions":          model.Settings.Submission.MaxVersions,
+		"AllowedExtensions":    model.Settings.Submission.AllowedExtensions,
+	})
+}
+
+// readNumberSetting copies received[key] into dst when it is present. Returns
+// false if it is present but not a non-negative number.
+func readNumberSetting(received map[string]interface{}, key string, dst *float64) bool {
+	raw, ok := received[key]
+	if !ok {
+		return true
+	}
+	value, ok := raw.(float64)
+	if !ok || value < 0 {
+		return false
+	}
+	*dst = value
+	return true
+}
+
+// RoutePOST_AdminRoundSettings updates the settings of the current round. Fields
+// that are left out of the request keep their current value.
+func RoutePOST_AdminRoundSettings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	received, _, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+
+	settings := model.Settings
+
+	reviewsPerUser := float64(settings.ReviewsPerUser)
+	minReviews := float64(settings.MinReviews)
+	minReviewLength := float64(settings.MinReviewLength)
+	maxFiles := float64(settings.Submission.MaxFiles)
+	maxFileBytes := float64(settings.Submission.MaxFileBytes)
+	maxTotalBytes := float64(settings.Submission.MaxTotalBytes)
+	maxVersions := float64(settings.Submission.MaxVersions)
+	numbers := map[string]*float64{
+		"ReviewsPerUser":      &reviewsPerUser,
+		"MinReviews":          &minReviews,
+		"MinReviewLength":     &minReviewLength,
+		"QuotaPenalty":        &settings.QuotaPenalty,
+		"HelpfulBonus":        &settings.HelpfulBonus,
+		"SimilarityThreshold": &settings.SimilarityThreshold,
+		"MaxFiles":            &maxFiles,
+		"MaxFileBytes":        &maxFileBytes,
+		"MaxTotalBytes":       &maxTotalBytes,
+		"MaxVersions":         &maxVersions,
+	}
+	for key, dst := range numbers {
+		if !readNumberSetting(received, key, dst) {
+			httpError(w, r, http.StatusBadRequest, "Invalid field '%s'", key)
+			return
+		}
+	}
+	// a share of fingerprints, from 0 to 1
+	if settings.SimilarityThreshold > 1 {
+		httpError(w, r, http.StatusBadRequest, "Invalid field '%s'", "SimilarityThreshold")
+		return
+	}
+	settings.ReviewsPerUser = int(reviewsPerUser)
+	settings.MinReviews = int(minReviews)
+	settings.MinReviewLength = int(minReviewLength)
+	settings.Submission.MaxFiles = int(maxFiles)
+	settings.Submission.MaxFileBytes = int(maxFileBytes)
+	settings.Submission.MaxTotalBytes = int(maxTotalBytes)
+	settings.Submission.MaxVersions = int(maxVersions)
+
+	if raw, ok := received["AllowedExtensions"]; ok {
+		list, ok := raw.([]interface{})
+		if !ok {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'AllowedExtensions'. Expects an array of strings like \".c\"")
+			return
+		}
+		extensions := make([]string, 0, len(list))
+		for _, value := range list {
+			ext, ok := value.(string)
+			if !ok || !strings.HasPrefix(ext, ".") {
+				httpError(w, r, http.StatusBadRequest, "Invalid field 'AllowedExtensions'. Expects an array of strings like \".c\"")
+				return
+			}
+			extensions = append(extensions, ext)
+		}
+		settings.Submission.AllowedExtensions = extensions
+	}
+
+	if raw, ok := received["RestrictToAssignment"]; ok {
+		restrict, ok := raw.(bool)
+		if !ok {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'RestrictToAssignment'")
+			return
+		}
+		settings.RestrictToAssignment = restrict
+	}
+
+	if raw, ok := received["BlindMode"]; ok {
+		name, _ := raw.(string)
+		found := false
+		for mode, modeName := range blindModeNames {
+			if modeName == name {
+				settings.Blind = mode
+				found = true
+			}
+		}
+		if !found {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'BlindMode'. Valid options are: \"none\", \"single\", \"double\"")
+			return
+		}
+	}
+
+	if raw, ok := received["Rubric"]; ok {
+		var rubric []model.RubricCriterion
+		jsonBytes, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(jsonBytes, &rubric)
+		}
+		if err != nil {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'Rubric'. Expects an array of objects: [{'Name': string, 'Scale': integer, 'Weight': number}]")
+			return
+		}
+		if err = model.ValidateRubric(rubric); err != nil {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'Rubric': %w", err)
+			return
+		}
+		settings.Rubric = rubric
+	}
+
+	model.Settings = settings
+	writeRoundSettings(w)
+}
+
+type similarityPairEntry struct {
+	AuthorA    string
+	AuthorB    string
+	Similarity float64
+	Flagged    bool
+	RegionsA   []model.CodeRegion
+	RegionsB   []model.CodeRegion
+}
+
+// RouteGET_AdminSimilarityReport returns the pairs of submissions that share code,
+// as computed at the end of the coding phase
+func RouteGET_AdminSimilarityReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	_, _, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+
+	pairs := make([]similarityPairEntry, 0, len(model.SimilarityReport))
+	flagged := make([]similarityPairEntry, 0)
+	for _, pair := range model.SimilarityReport {
+		var entry similarityPairEntry
+		entry.AuthorA = model.Users[pair.AuthorA].Name
+		entry.AuthorB = model.Users[pair.AuthorB].Name
+		entry.Similarity = pair.Similarity
+		entry.Flagged = pair.Flagged
+		pairs = append(pairs, entry)
+		if pair.Flagged {
+			entry.RegionsA = pair.RegionsA
+			entry.RegionsB = pair.RegionsB
+			flagged = append(flagged, entry)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Threshold": model.Settings.SimilarityThreshold,
+		"Pairs":     pairs,
+		"Flagged":   flagged,
+	})
+}
@@ -2,22 +2,76 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"mime"
 	"net/http"
+	"server/i18n"
 	"server/model"
 	"strconv"
-	"strings"
+	"time"
 )
 
 func RouteGET_CurrentChallenge(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed: Expected GET", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
 		return
 	}
 
+	lockRoom(r)
+	if problemHidden(w, r) {
+		model.Mutex.Unlock()
+		return
+	}
+	problem := *model.GetCurrentProblem()
+	problem.TestCases = make([]model.TestCase, len(problem.TestCases))
+	for i, testCase := range model.GetCurrentProblem().TestCases {
+		// external data may be huge, samples are downloaded from /api/sample_file instead
+		if testCase.External {
+			testCase.Input = ""
+			testCase.OutputJSON = nil
+			testCase.OutputText = ""
+		}
+		// only samples give away their expected output, like /api/sample_file
+		if !testCase.Sample {
+			testCase.OutputJSON = nil
+			testCase.OutputText = ""
+		}
+		problem.TestCases[i] = testCase
+	}
+	language := statementLanguage(r, problem.Languages)
+	model.Mutex.Unlock()
+
+	localized := problem.LocalizedStatement(language)
+	problem.Language = language
+	problem.Statement = localized.Statement
+	problem.StatementHTML = roomStatementHTML(localized.StatementHTML, requestRoom(r))
+
+	// send the statement as Markdown or as HTML
+	switch r.URL.Query().Get("format") {
+	case "", "markdown":
+		problem.StatementHTML = ""
+	case "html":
+		problem.Statement = ""
+	default:
+		httpError(w, r, http.StatusBadRequest, "Invalid parameter 'format', expected \"markdown\" or \"html\"")
+		return
+	}
+
+	// only send the starter template of the requested language
+	if language := r.URL.Query().Get("language"); language != "" {
+		var starters []model.StarterTemplate
+		for _, starter := range problem.Starters {
+			if starter.Language == language {
+				starters = append(starters, starter)
+			}
+		}
+		problem.Starters = starters
+	}
+
 	// Convert struct to JSON
-	jsonData, err := json.Marshal(model.GetCurrentProblem())
+	jsonData, err := json.Marshal(problem)
 	if err != nil {
-		http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+		httpError(w, r, http.StatusInternalServerError, "Failed to encode JSON")
 		return
 	}
 
@@ -31,7 +85,7 @@ func RouteGET_CurrentChallenge(w http.ResponseWriter, r *http.Request) {
 
 func RoutePOST_CheckSolution(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed: Expected POST", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
 		return
 	}
 
@@ -39,41 +93,41 @@ func RoutePOST_CheckSolution(w http.ResponseWriter, r *http.Request) {
 	var received map[string]interface{}
 	err := json.NewDecoder(r.Body).Decode(&received)
 	if err != nil {
-		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 
-	model.Mutex.Lock()
+	lockRoom(r)
 	defer model.Mutex.Unlock()
 
-	authed, _ := model.IsAuthedRequest(received)
-	if !authed {
-		http.Error(w, "Invalid UserId", http.StatusBadRequest)
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
 		return
 	}
 
-	caseIdx := received["TestCase"].(float64)
+	authed, userId := model.IsAuthedRequest(received)
+	if !authed {
+		httpError(w, r, http.StatusBadRequest, "Invalid UserId")
+		return
+	}
 
+	caseIdx, ok := received["TestCase"].(float64)
 	problem := model.GetCurrentProblem()
-	// Example: expected JSON object
-	if int(caseIdx) >= len(problem.TestCases) {
-		w.WriteHeader(http.StatusBadRequest)
+	if !ok || caseIdx < 0 || int(caseIdx) >= len(problem.TestCases) {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'TestCase'")
+		return
 	}
 
-	testCase := model.GetCurrentProblem().TestCases[int(caseIdx)]
-	expected := testCase.OutputJSON
-
-	jsonBytesExpected, err := json.Marshal(expected)
-	//fmt.Println("EXPECTED: ", string(jsonBytesExpected))
-
-	jsonBytesRecevied, err := json.Marshal(received["Output"])
-	//fmt.Println("RECEIVED: ", string(jsonBytesRecevied))
-
-	var isCorrect bool
-	if testCase.CaseSensitive {
-		isCorrect = string(jsonBytesRecevied) == string(jsonBytesExpected)
-	} else {
-		isCorrect = strings.EqualFold(string(jsonBytesRecevied), string(jsonBytesExpected))
+	testCase := &problem.TestCases[int(caseIdx)]
+	if err := testCase.LoadData(); err != nil {
+		fmt.Println("Failed to load test data:", err)
+		httpError(w, r, http.StatusInternalServerError, "Failed to load the test case's data")
+		return
+	}
+	isCorrect, err := model.RecordAttempt(userId, int(caseIdx), received["Output"])
+	if err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid field 'Output': %w", err)
+		return
 	}
 	// Check equality
 	if isCorrect {
@@ -85,74 +139,122 @@ func RoutePOST_CheckSolution(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeJSONError replies with {"Error": text of err}, for endpoints whose
+// clients expect JSON even when the request fails.
+func writeJSONError(w http.ResponseWriter, r *http.Request, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", requestLanguage(r))
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"Error": i18n.Localize(requestLanguage(r), err)})
+}
+
 func RoutePOST_Submit(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed: Expected POST", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Decode the JSON body into a map
 	var received map[string]interface{}
-	err := json.NewDecoder(r.Body).Decode(&received)
-	if err != nil {
-		http.Error(w, "{\"Error:\":\"Invalid JSON payload\"}", http.StatusBadRequest)
+	var srcFileList []model.SourceFile
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if isUploadMediaType(mediaType) {
+		var err error
+		received, srcFileList, err = readUploadedSubmission(w, r, mediaType)
+		if err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		// Decode the JSON body into a map, capped like uploads
+		limits := currentUploadLimits(r)
+		r.Body = http.MaxBytesReader(w, r.Body, int64(limits.maxTotal+uploadOverheadBytes))
+		err := json.NewDecoder(r.Body).Decode(&received)
+		if err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, i18n.Errorf("Invalid JSON payload"))
+			return
+		}
+
+		sourceFileMap, ok := received["SourceFiles"].([]interface{})
+		if !ok {
+			writeJSONError(w, r, http.StatusBadRequest, i18n.Errorf("Missing or invalid field 'SourceFiles'"))
+			return
+		}
+
+		for _, value := range sourceFileMap {
+			var srcFile model.SourceFile
+			// parse source files
+			sourceFileJSON, ok := value.(map[string]interface{})
+			if !ok {
+				writeJSONError(w, r, http.StatusBadRequest, i18n.Errorf("Improperly structured list of sourcefiles. Expects an array of objects: [{'Name': string, 'Code': string}]"))
+				return
+			}
+
+			nameStr, ok := sourceFileJSON["Name"].(string)
+			if !ok || nameStr == "" {
+				writeJSONError(w, r, http.StatusBadRequest, i18n.Errorf("Improperly structured list of sourcefiles. Expects an array of objects: [{'Name': string, 'Code': string}]"))
+				return
+			}
+
+			codeStr, ok := sourceFileJSON["Code"].(string)
+			if !ok || codeStr == "" {
+				writeJSONError(w, r, http.StatusBadRequest, i18n.Errorf("Improperly structured list of sourcefiles. Expects an array of objects: [{'Name': string, 'Code': string}]"))
+				return
+			}
+
+			srcFile.Name = nameStr
+			srcFile.Code = codeStr
+			srcFileList = append(srcFileList, srcFile)
+		}
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		writeJSONError(w, r, http.StatusForbidden, err)
 		return
 	}
 
 	authed, userId := model.IsAuthedRequest(received)
 	if !authed {
-		http.Error(w, "{\"Error:\":\"Invalid JSON payload\"}", http.StatusBadRequest)
+		writeJSONError(w, r, http.StatusBadRequest, i18n.Errorf("Invalid UserId"))
 		return
 	}
 
-	sourceFileMap, ok := received["SourceFiles"].([]interface{})
-	if !ok {
-		http.Error(w, "Missing or invalid field 'SourceFiles'", http.StatusBadRequest)
+	if err := model.ValidateSubmission(srcFileList); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	var srcFileList []model.SourceFile
-	for _, value := range sourceFileMap {
-		var srcFile model.SourceFile
-		// parse source files
-		sourceFileJSON, ok := value.(map[string]interface{})
-		if !ok {
-			http.Error(w, "{\"Error:\":\"Improperly structured list of sourcefiles. Expects an array of objects: [{'Name': string, 'Code': string}]}\"", http.StatusBadRequest)
-			return
-		}
-
-		nameStr, ok := sourceFileJSON["Name"].(string)
-		if !ok || nameStr == "" {
-			http.Error(w, "{\"Error:\":\"Improperly structured list of sourcefiles. Expects an array of objects: [{'Name': string, 'Code': string}]}\"", http.StatusBadRequest)
-			return
-		}
-
-		codeStr, ok := sourceFileJSON["Code"].(string)
-		if !ok || codeStr == "" {
-			http.Error(w, "{\"Error:\":\"Improperly structured list of sourcefiles. Expects an array of objects: [{'Name': string, 'Code': string}]}\"", http.StatusBadRequest)
-			return
-		}
+	manifest, err := model.ParseBuildManifest(srcFileList)
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err)
+		return
+	}
 
-		srcFile.Name = nameStr
-		srcFile.Code = codeStr
-		srcFileList = append(srcFileList, srcFile)
+	if err = model.AddSubmission(userId, srcFileList, manifest); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err)
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("{\"Error\":\"Success\"}"))
-	model.AddSubmission(userId, srcFileList)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Error":   "Success",
+		"Version": len(model.Submissions[userId].Versions),
+	})
 }
 
 // RoutePOST_GetUsers returns a list of all registered users
 func RoutePOST_GetUsers(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	model.Mutex.Lock()
+	lockRoom(r)
 	defer model.Mutex.Unlock()
 
 	// Build a response array
@@ -168,39 +270,60 @@ func RoutePOST_GetUsers(w http.ResponseWriter, r *http.Request) {
 }
 
 type publicCodeReview struct {
+	Id           uint32
 	ReviewerName string
 	Stars        uint8
+	Scores       map[string]uint8
 	Msg          string
+	Comments     []publicInlineComment
+	Edited       bool
+}
+
+func toPublicCodeReview(viewerId int32, authorId int32, review model.CodeReview) publicCodeReview {
+	var publicReview publicCodeReview
+	publicReview.Id = review.Id
+	publicReview.Msg = review.Msg
+	publicReview.ReviewerName = model.ReviewerDisplayName(viewerId, review.ReviewerId)
+	publicReview.Stars = review.Stars
+	publicReview.Scores = review.Scores
+	publicReview.Comments = publicComments(viewerId, authorId, review.ReviewerId, review.Comments)
+	publicReview.Edited = len(review.History) > 0
+	return publicReview
 }
 
 type publicSubmission struct {
-	Source  []model.SourceFile
-	Reviews []publicCodeReview
-	Author  string
+	Source            []model.SourceFile
+	Reviews           []publicCodeReview
+	Author            string
+	AverageStars      float64
+	AdjustedStars     float64
+	CriterionAverages map[string]float64
 }
 
 // RoutePOST_GetSubmissions returns all submissions, keyed by username
 func RoutePOST_GetSubmissions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed: Expected GET", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
 		return
 	}
 
 	// Decode request body (for auth)
 	var received map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
-		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 
-	auth, _ := model.IsAuthedRequest(received)
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	auth, viewerId := model.IsAuthedRequest(received)
 	if !auth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	model.Mutex.Lock()
-	defer model.Mutex.Unlock()
+	stats, globalMean, globalStdDev := model.ReviewerReputation()
 
 	// Build public map keyed by username
 	var submissionsPublic []publicSubmission
@@ -210,16 +333,17 @@ func RoutePOST_GetSubmissions(w http.ResponseWriter, r *http.Request) {
 			continue // skip unknown user IDs
 		}
 
+		reviews := model.VisibleReviews(privSubmission.CodeReviews)
+
 		var submission publicSubmission
-		for _, review := range privSubmission.CodeReviews {
-			var publicReviews publicCodeReview
-			publicReviews.Msg = review.Msg
-			publicReviews.ReviewerName = model.Users[review.ReviewerId].Name
-			publicReviews.Stars = review.Stars
-			submission.Reviews = append(submission.Reviews, publicReviews)
+		for _, review := range reviews {
+			submission.Reviews = append(submission.Reviews, toPublicCodeReview(viewerId, userId, review))
 		}
 		submission.Source = privSubmission.Source
-		submission.Author = user.Name
+		submission.Author = model.AuthorDisplayName(viewerId, user.Id)
+		submission.AverageStars = model.AverageStars(reviews)
+		submission.AdjustedStars = model.WeightedAdjustedStars(reviews, stats, globalMean, globalStdDev)
+		submission.CriterionAverages = model.CriterionAverages(reviews)
 		submissionsPublic = append(submissionsPublic, submission)
 	}
 
@@ -230,37 +354,32 @@ func RoutePOST_GetSubmissions(w http.ResponseWriter, r *http.Request) {
 func RouteGET_GetCodeReviews(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed: Expected GET", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
 		return
 	}
 
 	// Decode request body (for auth)
 	var received map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
-		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
 	auth, user_id := model.IsAuthedRequest(received)
 	if !auth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	model.Mutex.Lock()
-	defer model.Mutex.Unlock()
-
 	sub := model.Submissions[user_id]
 
 	var codeReviewsForUser []publicCodeReview
 
-	for _, privReview := range sub.CodeReviews {
-
-		var review publicCodeReview
-		review.Msg = privReview.Msg
-		review.ReviewerName = model.Users[privReview.ReviewerId].Name
-		review.Stars = privReview.Stars
-		codeReviewsForUser = append(codeReviewsForUser, review)
+	for _, privReview := range model.VisibleReviews(sub.CodeReviews) {
+		codeReviewsForUser = append(codeReviewsForUser, toPublicCodeReview(user_id, user_id, privReview))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -270,7 +389,7 @@ func RouteGET_GetCodeReviews(w http.ResponseWriter, r *http.Request) {
 func RoutePOST_JoinUser(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed: Expected POST", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
 		return
 	}
 
@@ -289,16 +408,25 @@ func RoutePOST_JoinUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	model.Mutex.Lock()
+	lockRoom(r)
 	defer model.Mutex.Unlock()
 	if model.IsUsernameTaken(username) {
 		w.Write([]byte(`{"Error":"name taken"}`))
 		return
 	}
+	if model.IsPseudonymName(username) {
+		w.Write([]byte(`{"Error":"name reserved"}`))
+		return
+	}
+
+	team, _ := received["Team"].(string)
+
+	adminKey, _ := received["AdminKey"].(string)
+	isAdmin := adminKey != "" && adminKey == model.AdminKey
 
 	var id int32
 
-	err, id = model.AddUser(username)
+	err, id = model.AddUser(username, team, isAdmin)
 
 	if err != nil {
 		w.Write([]byte(`{"Error":"err"}`))
@@ -313,116 +441,232 @@ func RoutePOST_JoinUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+type publicSpeedEntry struct {
+	Author   string
+	Solved   string
+	Seconds  int64 // from the start of the round
+	Attempts int
+}
+
+// RouteGET_SpeedLeaderboard ranks the users who solved the current problem, fastest first
 func RouteGET_SpeedLeaderboard(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed: Expected GET", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
 		return
 	}
 
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	leaderboard := make([]publicSpeedEntry, 0)
+	for _, entry := range model.SpeedLeaderboard() {
+		leaderboard = append(leaderboard, publicSpeedEntry{
+			Author:   model.AuthorDisplayName(0, entry.UserId),
+			Solved:   entry.Solved.Format(time.RFC3339),
+			Seconds:  int64(entry.Elapsed.Seconds()),
+			Attempts: entry.Attempts,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leaderboard)
 }
 
+type publicQualityEntry struct {
+	Author            string
+	AverageStars      float64
+	AdjustedStars     float64
+	ReviewCount       int
+	CriterionAverages map[string]float64
+}
+
+// RouteGET_QualityLeaderboard ranks the current round's submissions by their reviews
 func RouteGET_QualityLeaderboard(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed: Expected GET", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
 		return
 	}
 
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	leaderboard := make([]publicQualityEntry, 0)
+	for _, entry := range model.QualityLeaderboard() {
+		var publicEntry publicQualityEntry
+		// the leaderboard is public, so nobody is viewing as a known user
+		publicEntry.Author = model.AuthorDisplayName(0, entry.AuthorId)
+		publicEntry.AverageStars = entry.AverageStars
+		publicEntry.AdjustedStars = entry.AdjustedStars
+		publicEntry.ReviewCount = entry.ReviewCount
+		publicEntry.CriterionAverages = entry.Criteria
+		leaderboard = append(leaderboard, publicEntry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leaderboard)
 }
 
 func RouteGET_GetState(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed: Expected GET", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
 		return
 	}
 
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
 	w.Header().Set("Content-Type", "application/json")
 	if model.GetCycleState() == model.Coding {
 		w.Write([]byte("{\"State\":\"coding\"}"))
-	} else {
+	} else if model.GetCycleState() == model.Review {
 		w.Write([]byte("{\"State\":\"reviewing\"}"))
+	} else if model.GetCycleState() == model.Finished {
+		w.Write([]byte("{\"State\":\"finished\"}"))
+	} else if model.GetCycleState() == model.Lobby {
+		w.Write([]byte("{\"State\":\"lobby\"}"))
+	} else {
+		w.Write([]byte("{\"State\":\"results\"}"))
 	}
 }
 
 func RoutePOST_AddCodeReview(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed: Expected POST", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
 		return
 	}
 
 	// Decode request body
 	var received map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
-		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
 		return
 	}
 
 	auth, userId := model.IsAuthedRequest(received)
 	if !auth {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	targetUser, ok := received["TargetUser"].(string)
 	if !ok {
-		http.Error(w, "Missing or invalid field 'TargetUser'", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'TargetUser'")
 		return
 	}
 	reviewContents, ok := received["Review"].(string)
 	if !ok {
-		http.Error(w, "Missing or invalid field 'Review'", http.StatusBadRequest)
-		return
-	}
-	stars, ok := received["Stars"].(float64)
-	if !ok {
-		http.Error(w, "Missing or invalid field 'Stars'", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'Review'")
 		return
 	}
+
 	var review model.CodeReview
 	review.Msg = reviewContents
-	review.Stars = uint8(stars)
 	review.ReviewerId = userId
 
-	var targetUserId int32
-	var found bool = false
-	for _, user := range model.Users {
-		if user.Name == targetUser {
-			targetUserId = user.Id
-			found = true
-		}
+	var err error
+	review.Stars, review.Scores, err = parseRating(received)
+	if err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
 	}
+
+	targetUserId, found := model.FindAuthorByDisplayName(userId, targetUser)
 	if !found {
-		http.Error(w, "Invalid field 'TargetUser'", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Invalid field 'TargetUser'")
 		return
 	}
 
 	sub, ok := model.Submissions[targetUserId]
 	if !ok {
-		http.Error(w, "Missing or invalid field 'TargetUser'", http.StatusBadRequest)
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'TargetUser'")
+		return
+	}
+
+	if model.Settings.RestrictToAssignment && !model.IsReviewAssigned(userId, targetUserId) {
+		httpError(w, r, http.StatusForbidden, "'TargetUser' is not in your review assignments")
 		return
 	}
 
 	// check if the target user has already been reviewed
-	for _, review := range sub.CodeReviews {
-		if review.ReviewerId == userId {
-			http.Error(w, "Missing or invalid field 'TargetUser'", http.StatusBadRequest)
-			break
+	if model.HasReviewed(userId, targetUserId) {
+		httpError(w, r, http.StatusBadRequest, "You have already reviewed 'TargetUser'")
+		return
+	}
+
+	if commentsJSON, ok := received["Comments"].([]interface{}); ok && len(commentsJSON) > 0 {
+		if err := model.InlineCommentsOpen(); err != nil {
+			httpErrorFrom(w, r, http.StatusBadRequest, err)
+			return
+		}
+		for _, value := range commentsJSON {
+			comment, err := parseInlineComment(value, targetUserId)
+			if err != nil {
+				httpError(w, r, http.StatusBadRequest, "Invalid field 'Comments': %w", err)
+				return
+			}
+			review.Comments = append(review.Comments, comment)
 		}
 	}
 
+	review.Id = model.NewReviewId()
+	for i := range review.Comments {
+		review.Comments[i].Id = model.NewCommentId()
+	}
 	sub.CodeReviews = append(sub.CodeReviews, review)
 	model.Submissions[targetUserId] = sub
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ReviewId": review.Id})
+}
+
+// parseRating reads the review's rating: 'Scores' when the round has a rubric,
+// 'Stars' otherwise.
+func parseRating(received map[string]interface{}) (uint8, map[string]uint8, error) {
+	rubric := model.CurrentRubric()
+	if len(rubric) == 0 {
+		stars, ok := received["Stars"].(float64)
+		if !ok {
+			return 0, nil, i18n.Errorf("Missing or invalid field 'Stars'")
+		}
+		return uint8(min(max(stars, 1), 5)), nil, nil
+	}
+
+	scoresJSON, ok := received["Scores"].(map[string]interface{})
+	if !ok {
+		return 0, nil, i18n.Errorf("Missing or invalid field 'Scores'")
+	}
+	scores := make(map[string]uint8)
+	for name, raw := range scoresJSON {
+		score, ok := raw.(float64)
+		if !ok || score < 0 || score > 255 {
+			return 0, nil, i18n.Errorf("Invalid score for '%s' in field 'Scores'", name)
+		}
+		scores[name] = uint8(score)
+	}
+	if err := model.ValidateScores(rubric, scores); err != nil {
+		return 0, nil, i18n.Errorf("Invalid field 'Scores': %w", err)
+	}
+	return model.StarsFromScores(rubric, scores), scores, nil
 }
 
 func RoutePOST_GetCycleTimeLeft(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed: Expected GET", http.StatusMethodNotAllowed)
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
 		return
 	}
 
+	lockRoom(r)
 	secAsStr := strconv.FormatInt(int64(model.GetCycleTimeLeftSeconds()), 10)
+	model.Mutex.Unlock()
 	var str string
 	str = str + "{\"SecondsRemaining\":" + secAsStr + "}"
 
@@ -430,3 +674,170 @@ func RoutePOST_GetCycleTimeLeft(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte(str))
 }
+
+type publicReviewAssignment struct {
+	Author   string
+	Reviewed bool
+}
+
+// RouteGET_MyReviewAssignments returns the submissions the user was assigned to review
+func RouteGET_MyReviewAssignments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	// Decode request body (for auth)
+	var received map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	assignments := make([]publicReviewAssignment, 0, len(model.ReviewAssignments[userId]))
+	for _, authorId := range model.ReviewAssignments[userId] {
+		var assignment publicReviewAssignment
+		assignment.Author = model.AuthorDisplayName(userId, authorId)
+		assignment.Reviewed = model.HasReviewed(userId, authorId)
+		assignments = append(assignments, assignment)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(assignments)
+}
+
+type publicReviewerStats struct {
+	Reviewer    string
+	ReviewCount int
+	MeanStars   float64
+	StdDev      float64
+	Agreement   float64
+	Weight      float64
+}
+
+// RouteGET_ReviewerReputation returns how every reviewer is calibrated on the quality leaderboard
+func RouteGET_ReviewerReputation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	reputation := make([]publicReviewerStats, 0)
+	for _, stats := range model.SortedReviewerReputation() {
+		if !model.IsValidUserId(stats.ReviewerId) {
+			continue
+		}
+		var entry publicReviewerStats
+		entry.Reviewer = model.ReviewerDisplayName(0, stats.ReviewerId)
+		entry.ReviewCount = stats.ReviewCount
+		entry.MeanStars = stats.MeanStars
+		entry.StdDev = stats.StdDev
+		entry.Agreement = stats.Agreement
+		entry.Weight = stats.Weight
+		reputation = append(reputation, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reputation)
+}
+
+// RouteGET_ReviewQuota returns how many reviews the user still has to write this round
+func RouteGET_ReviewQuota(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	// Decode request body (for auth)
+	var received map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	required := model.RequiredReviewCount(userId)
+	completed := model.CompletedReviewCount(userId)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Required":         required,
+		"Completed":        completed,
+		"MinReviewLength":  model.Settings.MinReviewLength,
+		"Met":              completed >= required,
+		"PenaltyPerReview": model.Settings.QuotaPenalty,
+	})
+}
+
+type publicCumulativeEntry struct {
+	Name          string
+	Points        float64
+	QualityPoints float64
+	ReviewPenalty float64
+	ReviewBonus   float64
+	Rounds        int
+}
+
+// RouteGET_CumulativeLeaderboard ranks users by the points of all finished rounds
+func RouteGET_CumulativeLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	leaderboard := make([]publicCumulativeEntry, 0)
+	for _, userId := range model.CumulativeLeaderboard() {
+		score := model.CumulativeScores[userId]
+		leaderboard = append(leaderboard, publicCumulativeEntry{
+			Name:          model.Users[userId].Name,
+			Points:        score.Points,
+			QualityPoints: score.QualityPoints,
+			ReviewPenalty: score.ReviewPenalty,
+			ReviewBonus:   score.ReviewBonus,
+			Rounds:        score.Rounds,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leaderboard)
+}
+
+// RouteGET_Toolchains returns the languages a build manifest may use
+func RouteGET_Toolchains(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"Toolchains": model.Toolchains,
+		"Rules":      model.CurrentManifestRules(),
+	})
+}
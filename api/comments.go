@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/i18n"
+	"server/model"
+)
+
+type publicCommentReply struct {
+	Author string
+	Msg    string
+}
+
+type publicInlineComment struct {
+	Id        uint32
+	File      string
+	StartLine int
+	EndLine   int
+	Msg       string
+	Replies   []publicCommentReply
+}
+
+func publicComments(viewerId int32, authorId int32, reviewerId int32, comments []model.InlineComment) []publicInlineComment {
+	var result []publicInlineComment
+	for _, comment := range comments {
+		var publicComment publicInlineComment
+		publicComment.Id = comment.Id
+		publicComment.File = comment.File
+		publicComment.StartLine = comment.StartLine
+		publicComment.EndLine = comment.EndLine
+		publicComment.Msg = comment.Msg
+		for _, reply := range comment.Replies {
+			var publicReply publicCommentReply
+			if reply.AuthorId == authorId {
+				publicReply.Author = model.AuthorDisplayName(viewerId, authorId)
+			} else {
+				publicReply.Author = model.ReviewerDisplayName(viewerId, reviewerId)
+			}
+			publicReply.Msg = reply.Msg
+			publicComment.Replies = append(publicComment.Replies, publicReply)
+		}
+		result = append(result, publicComment)
+	}
+	return result
+}
+
+// parseInlineComment reads a {'File', 'StartLine', 'EndLine', 'Msg'} object
+// and validates it against authorId's submission.
+func parseInlineComment(value interface{}, authorId int32) (model.InlineComment, error) {
+	commentJSON, ok := value.(map[string]interface{})
+	if !ok {
+		return model.InlineComment{}, i18n.Errorf("expects an object: {'File': string, 'StartLine': integer, 'EndLine': integer, 'Msg': string}")
+	}
+	file, ok := commentJSON["File"].(string)
+	if !ok {
+		return model.InlineComment{}, i18n.Errorf("missing or invalid field 'File'")
+	}
+	startLine, ok := commentJSON["StartLine"].(float64)
+	if !ok {
+		return model.InlineComment{}, i18n.Errorf("missing or invalid field 'StartLine'")
+	}
+	endLine, ok := commentJSON["EndLine"].(float64)
+	if !ok {
+		endLine = startLine
+	}
+	msg, ok := commentJSON["Msg"].(string)
+	if !ok {
+		return model.InlineComment{}, i18n.Errorf("missing or invalid field 'Msg'")
+	}
+	return model.NewInlineComment(authorId, file, int(startLine), int(endLine), msg)
+}
+
+// RoutePOST_AddInlineComment attaches a comment to a line range of a submission
+// the user has already reviewed
+func RoutePOST_AddInlineComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	var received map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	targetUser, ok := received["TargetUser"].(string)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'TargetUser'")
+		return
+	}
+	targetUserId, found := model.FindAuthorByDisplayName(userId, targetUser)
+	if !found {
+		httpError(w, r, http.StatusBadRequest, "Invalid field 'TargetUser'")
+		return
+	}
+
+	comment, err := parseInlineComment(received, targetUserId)
+	if err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid comment: %w", err)
+		return
+	}
+
+	commentId, err := model.AddInlineComment(targetUserId, userId, comment)
+	if err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"CommentId": commentId})
+}
+
+// RoutePOST_ReplyInlineComment adds a reply to an inline comment thread
+func RoutePOST_ReplyInlineComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	var received map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// authors reply on their own submission, so 'TargetUser' is optional for them
+	targetUserId := userId
+	if targetUser, ok := received["TargetUser"].(string); ok {
+		var found bool
+		targetUserId, found = model.FindAuthorByDisplayName(userId, targetUser)
+		if !found {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'TargetUser'")
+			return
+		}
+	}
+
+	commentId, ok := received["CommentId"].(float64)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'CommentId'")
+		return
+	}
+	msg, ok := received["Msg"].(string)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'Msg'")
+		return
+	}
+
+	if err := model.ReplyToComment(targetUserId, uint32(commentId), userId, msg); err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/i18n"
+	"server/model"
+	"strings"
+	"time"
+)
+
+type publicErrata struct {
+	Problem     string
+	FromVersion int
+	ToVersion   int
+	Time        string
+	Note        string
+}
+
+func toPublicErrata(errata model.Errata) publicErrata {
+	return publicErrata{
+		Problem:     errata.Problem,
+		FromVersion: errata.FromVersion,
+		ToVersion:   errata.ToVersion,
+		Time:        errata.Time.Format(time.RFC3339),
+		Note:        errata.Note,
+	}
+}
+
+type publicRejudge struct {
+	publicErrata
+	Rejudged int
+	Changed  int
+	Affected []string
+}
+
+// RoutePOST_AdminApplyErrata publishes the version of the problem in play that
+// was reloaded from disk, and rejudges every attempt against it
+func RoutePOST_AdminApplyErrata(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	received, userId, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	note := ""
+	if raw, ok := received["Note"]; ok {
+		note, ok = raw.(string)
+		if !ok {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'Note'")
+			return
+		}
+	}
+
+	errata, err := model.ApplyErrata(strings.TrimSpace(note))
+	if err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := publicRejudge{
+		publicErrata: toPublicErrata(*errata),
+		Rejudged:     errata.Rejudged,
+		Changed:      errata.Changed,
+		Affected:     make([]string, 0, len(errata.Affected)),
+	}
+	for _, affectedId := range errata.Affected {
+		resp.Affected = append(resp.Affected, model.AuthorDisplayName(userId, affectedId))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RouteGET_Errata lists the errata published for the current round
+func RouteGET_Errata(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	errata := make([]publicErrata, 0)
+	for _, e := range model.CurrentErrata() {
+		errata = append(errata, toPublicErrata(e))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(errata)
+}
+
+type publicNotification struct {
+	Id        uint32
+	Time      string
+	Message   string
+	Problem   string
+	Version   int
+	Note      string
+	Changed   int
+	WasSolved bool
+	Solved    bool
+}
+
+// notificationMessage explains in language what the errata changed for the user
+func notificationMessage(language string, notification model.Notification) string {
+	lines := []string{i18n.Sprintf(language, "'%s' was corrected, it is now at version %d.", notification.Problem, notification.Version)}
+	if notification.Note != "" {
+		lines = append(lines, notification.Note)
+	}
+	if notification.Changed > 0 {
+		lines = append(lines, i18n.Sprintf(language, "The verdict of %d of your attempts changed.", notification.Changed))
+	}
+	if notification.Solved && !notification.WasSolved {
+		lines = append(lines, i18n.Sprintf(language, "You have now passed every test case."))
+	} else if !notification.Solved && notification.WasSolved {
+		lines = append(lines, i18n.Sprintf(language, "You no longer pass every test case."))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RouteGET_Notifications returns the user's notifications, optionally only
+// those after the one with id 'Since'
+func RouteGET_Notifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	var received map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	var since uint32
+	if raw, ok := received["Since"]; ok {
+		value, ok := raw.(float64)
+		if !ok || value < 0 {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'Since'")
+			return
+		}
+		since = uint32(value)
+	}
+
+	language := requestLanguage(r)
+	notifications := make([]publicNotification, 0)
+	for _, notification := range model.Notifications[userId] {
+		if notification.Id <= since {
+			continue
+		}
+		notifications = append(notifications, publicNotification{
+			Id:        notification.Id,
+			Time:      notification.Time.Format(time.RFC3339),
+			Message:   notificationMessage(language, notification),
+			Problem:   notification.Problem,
+			Version:   notification.Version,
+			Note:      notification.Note,
+			Changed:   notification.Changed,
+			WasSolved: notification.WasSolved,
+			Solved:    notification.Solved,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", language)
+	json.NewEncoder(w).Encode(notifications)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/i18n"
+	"server/model"
+	"slices"
+	"strconv"
+)
+
+// requestLanguage picks the language of a request's messages: the 'lang'
+// query parameter if it is supported, then the Accept-Language header.
+func requestLanguage(r *http.Request) string {
+	languages := i18n.Languages()
+	if language := r.URL.Query().Get("lang"); slices.Contains(languages, language) {
+		return language
+	}
+	if language := i18n.Match(r.Header.Get("Accept-Language"), languages); language != "" {
+		return language
+	}
+	return i18n.DefaultLanguage
+}
+
+// statementLanguage picks which of a problem statement's languages to send:
+// the one asked for by the 'lang' query parameter, then the preference of the
+// user given by the 'UserId' query parameter, then the Accept-Language header.
+// The caller must hold model.Mutex.
+func statementLanguage(r *http.Request, languages []string) string {
+	query := r.URL.Query()
+	if language := i18n.Match(query.Get("lang"), languages); language != "" {
+		return language
+	}
+	if userId, err := strconv.ParseInt(query.Get("UserId"), 10, 32); err == nil {
+		if language := i18n.Match(model.Users[int32(userId)].Language, languages); language != "" {
+			return language
+		}
+	}
+	if language := i18n.Match(r.Header.Get("Accept-Language"), languages); language != "" {
+		return language
+	}
+	return languages[0]
+}
+
+// httpError replies with the translation of an error message, see http.Error.
+func httpError(w http.ResponseWriter, r *http.Request, code int, format string, args ...interface{}) {
+	w.Header().Set("Content-Language", requestLanguage(r))
+	http.Error(w, i18n.Sprintf(requestLanguage(r), format, args...), code)
+}
+
+// httpErrorFrom replies with the text of err, translated if it was made by i18n.Errorf.
+func httpErrorFrom(w http.ResponseWriter, r *http.Request, code int, err error) {
+	w.Header().Set("Content-Language", requestLanguage(r))
+	http.Error(w, i18n.Localize(requestLanguage(r), err), code)
+}
+
+// RoutePOST_SetLanguage sets the language the user prefers problem statements in
+func RoutePOST_SetLanguage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	var received map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	language, ok := received["Language"].(string)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'Language'")
+		return
+	}
+	if err := model.SetUserLanguage(userId, language); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid field 'Language'")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
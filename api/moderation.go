@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/model"
+	"time"
+)
+
+// decodeReviewRequest decodes a POST body that refers to a review by 'ReviewId'.
+// The caller must hold model.Mutex. On failure an error has already been
+// written to w and ok is false.
+func decodeReviewRequest(w http.ResponseWriter, r *http.Request) (received map[string]interface{}, userId int32, reviewId uint32, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return nil, 0, 0, false
+	}
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return nil, 0, 0, false
+	}
+
+	id, ok := received["ReviewId"].(float64)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'ReviewId'")
+		return nil, 0, 0, false
+	}
+	return received, userId, uint32(id), true
+}
+
+// RoutePOST_EditCodeReview changes the rating or message of one of the user's reviews
+func RoutePOST_EditCodeReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	received, userId, reviewId, ok := decodeReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	_, review, found := model.FindCodeReview(reviewId)
+	if !found || review.ReviewerId != userId {
+		httpError(w, r, http.StatusBadRequest, "Invalid field 'ReviewId'")
+		return
+	}
+
+	// fields that are left out keep their current value
+	stars, scores, msg := review.Stars, review.Scores, review.Msg
+	if raw, ok := received["Review"]; ok {
+		msg, ok = raw.(string)
+		if !ok {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'Review'")
+			return
+		}
+	}
+	_, hasStars := received["Stars"]
+	_, hasScores := received["Scores"]
+	if hasStars || hasScores {
+		var err error
+		stars, scores, err = parseRating(received)
+		if err != nil {
+			httpErrorFrom(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := model.EditCodeReview(reviewId, userId, stars, scores, msg); err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
+
+// RoutePOST_DeleteCodeReview retracts one of the user's reviews
+func RoutePOST_DeleteCodeReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	_, userId, reviewId, ok := decodeReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := model.RetractCodeReview(reviewId, userId); err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
+
+// RoutePOST_FlagCodeReview reports a review of the user's submission to the admins
+func RoutePOST_FlagCodeReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	received, userId, reviewId, ok := decodeReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	reason, _ := received["Reason"].(string)
+
+	if err := model.FlagCodeReview(reviewId, userId, reason); err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
+
+type publicReviewRevision struct {
+	Stars  uint8
+	Scores map[string]uint8
+	Msg    string
+	Time   time.Time
+}
+
+type moderationQueueEntry struct {
+	ReviewId   uint32
+	Author     string
+	Reviewer   string
+	Stars      uint8
+	Msg        string
+	FlagReason string
+	History    []publicReviewRevision
+}
+
+// RouteGET_AdminModerationQueue lists the flagged reviews waiting for moderation
+func RouteGET_AdminModerationQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	_, _, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+
+	queue := make([]moderationQueueEntry, 0)
+	for _, item := range model.ModerationQueue() {
+		var entry moderationQueueEntry
+		entry.ReviewId = item.Review.Id
+		entry.Author = model.Users[item.AuthorId].Name
+		entry.Reviewer = model.Users[item.Review.ReviewerId].Name
+		entry.Stars = item.Review.Stars
+		entry.Msg = item.Review.Msg
+		entry.FlagReason = item.Review.FlagReason
+		for _, revision := range item.Review.History {
+			entry.History = append(entry.History, publicReviewRevision(revision))
+		}
+		queue = append(queue, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(queue)
+}
+
+// RoutePOST_AdminModerateReview hides a flagged review or dismisses the flag
+func RoutePOST_AdminModerateReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	received, _, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+
+	reviewId, ok := received["ReviewId"].(float64)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'ReviewId'")
+		return
+	}
+
+	action, _ := received["Action"].(string)
+	if action != "hide" && action != "dismiss" {
+		httpError(w, r, http.StatusBadRequest, "Invalid field 'Action'. Valid options are: \"hide\", \"dismiss\"")
+		return
+	}
+
+	if err := model.ModerateCodeReview(uint32(reviewId), action == "hide"); err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
+
+// RoutePOST_RateCodeReview lets the author of a submission rate how helpful a review was
+func RoutePOST_RateCodeReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	if err := contestClosed(); err != nil {
+		httpErrorFrom(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	received, userId, reviewId, ok := decodeReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rating, ok := received["Helpful"].(float64)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'Helpful'")
+		return
+	}
+
+	if err := model.RateCodeReview(reviewId, userId, uint8(min(max(rating, 0), 255))); err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/model"
+	"time"
+)
+
+type reloadStatus struct {
+	LastReload     string
+	LastError      string
+	ErrataPending  bool
+	ProblemRemoved bool
+	Problem        string
+	Version        int    // of the problem in play
+	Revision       string // of the problem in play
+	LatestVersion  int    // of the problem on disk, 0 if it was removed
+	LatestRevision string // of the problem on disk, empty if it was removed
+}
+
+// RouteGET_AdminReloadStatus reports how the last reload of the problem files went
+func RouteGET_AdminReloadStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	_, _, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+
+	problem := model.GetCurrentProblem()
+	status := reloadStatus{
+		LastError:      model.ProblemsReload.Error,
+		ErrataPending:  model.ProblemsReload.ErrataPending,
+		ProblemRemoved: model.ProblemsReload.Removed,
+		Problem:        problem.Header.Name,
+		Version:        problem.Version,
+		Revision:       problem.Revision,
+	}
+	if !model.ProblemsReload.Time.IsZero() {
+		status.LastReload = model.ProblemsReload.Time.Format(time.RFC3339)
+	}
+	for i := range model.ProblemList {
+		if model.ProblemList[i].Header.Name == problem.Header.Name {
+			status.LatestVersion = model.ProblemList[i].Version
+			status.LatestRevision = model.ProblemList[i].Revision
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"server/model"
+	"strings"
+)
+
+type roomContextKey struct{}
+
+// requestRoom returns the room a request is scoped to, the default room for
+// the routes without a room id.
+func requestRoom(r *http.Request) *model.Room {
+	if room, ok := r.Context().Value(roomContextKey{}).(*model.Room); ok {
+		return room
+	}
+	return model.DefaultRoom()
+}
+
+// lockRoom locks model.Mutex and selects the room of the request. Unlock with
+// model.Mutex.Unlock.
+func lockRoom(r *http.Request) {
+	model.Mutex.Lock()
+	model.UseRoom(requestRoom(r))
+}
+
+// RoomRoutes serves the routes of next under /api/rooms/{room}/, scoped to the
+// room with that id: /api/rooms/beginner/challenge is /api/challenge of the
+// room "beginner". Rooms are all added before the server starts.
+func RoomRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("room")
+		room, ok := model.Rooms[id]
+		if !ok {
+			httpError(w, r, http.StatusNotFound, "Unknown room '%s'", id)
+			return
+		}
+
+		scoped := r.Clone(context.WithValue(r.Context(), roomContextKey{}, room))
+		scoped.URL.Path = "/api/" + strings.TrimPrefix(r.URL.Path, "/api/rooms/"+id+"/")
+		scoped.URL.RawPath = ""
+		next.ServeHTTP(w, scoped)
+	})
+}
+
+// statementImagePath is where statements rendered at load link their images.
+// The rooms share the rendering, so the links are pointed at the room of each
+// request by roomStatementHTML.
+const statementImagePath = "/api/statement_image"
+
+// StatementImageURL links the image src of a statement.
+func StatementImageURL(src string) string {
+	return statementImagePath + "?name=" + url.QueryEscape(src)
+}
+
+// roomStatementHTML points the images of statement, linked by
+// StatementImageURL, at /api/rooms/{room}/statement_image. Text of the
+// statement can't match, its quotes are escaped.
+func roomStatementHTML(statement string, room *model.Room) string {
+	return strings.ReplaceAll(statement, `src="`+statementImagePath+"?",
+		`src="/api/rooms/`+url.PathEscape(room.Id)+"/statement_image?")
+}
+
+type publicRoom struct {
+	Id               string
+	State            string
+	SecondsRemaining int64
+	Problem          string // empty before the contest starts
+	Users            int
+}
+
+// RouteGET_Rooms lists the rooms of the server, the default room first
+func RouteGET_Rooms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	model.Mutex.Lock()
+	defer model.Mutex.Unlock()
+
+	rooms := make([]publicRoom, 0, len(model.Rooms))
+	defaultRoom := model.DefaultRoom()
+	for _, room := range model.RoomList() {
+		model.UseRoom(room)
+		entry := publicRoom{
+			Id:               room.Id,
+			State:            cycleNames[model.GetCycleState()],
+			SecondsRemaining: int64(model.GetCycleTimeLeftSeconds()),
+			Users:            len(model.Users),
+		}
+		if model.GetCycleState() != model.Lobby {
+			entry.Problem = model.GetCurrentProblem().Header.Name
+		}
+		if room == defaultRoom {
+			rooms = append([]publicRoom{entry}, rooms...)
+		} else {
+			rooms = append(rooms, entry)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rooms)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/i18n"
+	"server/model"
+	"time"
+)
+
+var scheduleOrderNames = map[model.ScheduleOrder]string{
+	model.FileOrder:       "file",
+	model.PlaylistOrder:   "playlist",
+	model.DifficultyOrder: "difficulty",
+	model.RandomOrder:     "random",
+}
+
+var exhaustedActionNames = map[model.ExhaustedAction]string{
+	model.LoopSchedule:      "loop",
+	model.StopSchedule:      "stop",
+	model.ReshuffleSchedule: "reshuffle",
+}
+
+type scheduledProblem struct {
+	Name          string
+	Difficulty    model.ProblemDifficulty
+	CodingMinutes float64
+	ReviewMinutes float64
+}
+
+type publicSchedule struct {
+	Order         string
+	WhenExhausted string
+	Seed          uint64
+	Start         string
+	End           string
+	Finished      bool
+	Current       scheduledProblem
+	Upcoming      []scheduledProblem // the rest of this pass through the problems
+}
+
+func toScheduledProblem(problem *model.Problem) scheduledProblem {
+	coding, review := model.ProblemPhaseDurations(problem)
+	return scheduledProblem{
+		Name:          problem.Header.Name,
+		Difficulty:    problem.Difficulty,
+		CodingMinutes: coding.Minutes(),
+		ReviewMinutes: review.Minutes(),
+	}
+}
+
+// RouteGET_AdminSchedule returns the schedule and the problems coming up next
+func RouteGET_AdminSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	_, _, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+
+	schedule := publicSchedule{
+		Order:         scheduleOrderNames[model.CurrentSchedule.Order],
+		WhenExhausted: exhaustedActionNames[model.CurrentSchedule.WhenExhausted],
+		Seed:          model.CurrentSchedule.Seed,
+		Start:         contestTime(model.CurrentSchedule.Start),
+		End:           contestTime(model.CurrentSchedule.End),
+		Finished:      model.ScheduleFinished(),
+		Current:       toScheduledProblem(model.GetCurrentProblem()),
+		Upcoming:      make([]scheduledProblem, 0),
+	}
+	for _, name := range model.UpcomingProblems() {
+		if problem := model.FindProblem(name); problem != nil {
+			schedule.Upcoming = append(schedule.Upcoming, toScheduledProblem(problem))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+var cycleNames = map[model.CycleTime]string{
+	model.Coding:   "coding",
+	model.Review:   "reviewing",
+	model.Results:  "results",
+	model.Finished: "finished",
+	model.Lobby:    "lobby",
+}
+
+type publicTimelinePhase struct {
+	State string
+	Start string
+	End   string
+}
+
+type publicTimelineRound struct {
+	Round      uint32
+	Problem    string // only given for the current round
+	Difficulty model.ProblemDifficulty
+	Phases     []publicTimelinePhase
+}
+
+type publicTimeline struct {
+	State            string
+	SecondsRemaining int64
+	Start            string // empty if the contest started with the server
+	End              string // empty if the contest has no end
+	TimeZone         string
+	Rounds           []publicTimelineRound
+}
+
+// contestTime formats t in the contest's time zone, or returns "" for the zero time.
+func contestTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(model.ContestLocation()).Format(time.RFC3339)
+}
+
+// RouteGET_Timeline returns when the phases of the current and upcoming rounds start and end
+func RouteGET_Timeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	timeline := publicTimeline{
+		State:            cycleNames[model.GetCycleState()],
+		SecondsRemaining: int64(model.GetCycleTimeLeftSeconds()),
+		Start:            contestTime(model.CurrentSchedule.Start),
+		End:              contestTime(model.CurrentSchedule.End),
+		TimeZone:         model.ContestLocation().String(),
+		Rounds:           make([]publicTimelineRound, 0),
+	}
+	for i, round := range model.Timeline() {
+		publicRound := publicTimelineRound{Round: round.Round, Difficulty: round.Problem.Difficulty}
+		if i == 0 && model.GetCycleState() != model.Lobby {
+			publicRound.Problem = round.Problem.Header.Name
+		}
+		for _, phase := range round.Phases {
+			publicRound.Phases = append(publicRound.Phases, publicTimelinePhase{
+				State: cycleNames[phase.Cycle],
+				Start: contestTime(phase.Start),
+				End:   contestTime(phase.End),
+			})
+		}
+		timeline.Rounds = append(timeline.Rounds, publicRound)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// contestClosed returns why work can't be handed in right now: before the
+// contest starts and once its results are final. Nil while it runs.
+// The caller must hold model.Mutex.
+func contestClosed() error {
+	switch model.GetCycleState() {
+	case model.Lobby:
+		return i18n.Errorf("The contest has not started yet")
+	case model.Finished:
+		return i18n.Errorf("The contest is over, the results are final")
+	}
+	return nil
+}
+
+// problemHidden writes an error and returns true while the contest hasn't
+// started, so the problem isn't revealed early. The caller must hold model.Mutex.
+func problemHidden(w http.ResponseWriter, r *http.Request) bool {
+	if model.GetCycleState() != model.Lobby {
+		return false
+	}
+	httpError(w, r, http.StatusForbidden, "The contest has not started yet")
+	return true
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"server/model"
+)
+
+// RouteGET_StatementImage returns an image embedded in the current problem's statement
+func RouteGET_StatementImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	lockRoom(r)
+	if problemHidden(w, r) {
+		model.Mutex.Unlock()
+		return
+	}
+	data, ok := model.GetCurrentProblem().Images[name]
+	model.Mutex.Unlock()
+	if !ok {
+		httpError(w, r, http.StatusNotFound, "Image not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
+}
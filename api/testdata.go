@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"path"
+	"server/model"
+	"strconv"
+)
+
+// RouteGET_SampleFile downloads a data file of one of the current problem's sample test cases
+func RouteGET_SampleFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	query := r.URL.Query()
+	lockRoom(r)
+	if problemHidden(w, r) {
+		model.Mutex.Unlock()
+		return
+	}
+	problem := model.GetCurrentProblem()
+	caseIdx, err := strconv.Atoi(query.Get("case"))
+	if err != nil || caseIdx < 0 || caseIdx >= len(problem.TestCases) {
+		model.Mutex.Unlock()
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid parameter 'case'")
+		return
+	}
+	// the data is taken from the loaded test case, the files may have been
+	// edited since while the problem is pinned
+	loadErr := problem.TestCases[caseIdx].LoadData()
+	testCase := problem.TestCases[caseIdx]
+	model.Mutex.Unlock()
+
+	var file *model.TestDataFile
+	switch query.Get("file") {
+	case "input":
+		file = testCase.InputFile
+	case "output":
+		file = testCase.OutputFile
+	default:
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid parameter 'file', expected \"input\" or \"output\"")
+		return
+	}
+	if !testCase.Sample {
+		httpError(w, r, http.StatusForbidden, "Forbidden: test case is not a sample")
+		return
+	}
+	if file == nil {
+		httpError(w, r, http.StatusNotFound, "Test case has no such file")
+		return
+	}
+
+	if loadErr != nil {
+		httpError(w, r, http.StatusInternalServerError, "Failed to read the test data")
+		return
+	}
+	data := []byte(testCase.Input)
+	if query.Get("file") == "output" {
+		data = []byte(testCase.OutputText)
+		if testCase.Mode == model.JSONMode {
+			data, err = json.Marshal(testCase.OutputJSON)
+			if err != nil {
+				httpError(w, r, http.StatusInternalServerError, "Failed to read the test data")
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(file.Path)}))
+	w.Write(data)
+}
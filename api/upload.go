@@ -0,0 +1,245 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"server/i18n"
+	"server/model"
+	"strconv"
+	"strings"
+)
+
+// Limits for policies that leave a value unlimited, so an upload can never
+// make the server unpack an arbitrary amount of data.
+const maxUnpackedBytes = 16 * 1024 * 1024
+const maxArchiveEntries = 1000
+
+// uploadOverheadBytes is the room left for multipart headers, archive metadata
+// and JSON encoding on top of the submission's own size.
+const uploadOverheadBytes = 1024 * 1024
+
+type uploadLimits struct {
+	maxFiles     int
+	maxFileBytes int
+	maxTotal     int
+}
+
+func currentUploadLimits(r *http.Request) uploadLimits {
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	policy := model.Settings.Submission
+	limits := uploadLimits{policy.MaxFiles, policy.MaxFileBytes, policy.MaxTotalBytes}
+	if limits.maxTotal <= 0 || limits.maxTotal > maxUnpackedBytes {
+		limits.maxTotal = maxUnpackedBytes
+	}
+	if limits.maxFileBytes <= 0 || limits.maxFileBytes > limits.maxTotal {
+		limits.maxFileBytes = limits.maxTotal
+	}
+	if limits.maxFiles <= 0 || limits.maxFiles > maxArchiveEntries {
+		limits.maxFiles = maxArchiveEntries
+	}
+	return limits
+}
+
+func isUploadMediaType(mediaType string) bool {
+	switch mediaType {
+	case "multipart/form-data", "application/zip", "application/gzip", "application/x-gzip", "application/x-compressed-tar":
+		return true
+	}
+	return false
+}
+
+func isArchiveName(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// unpacker collects the files of an upload while enforcing the limits on
+// their number and unpacked size, whatever the archive headers claim.
+type unpacker struct {
+	limits uploadLimits
+	files  []model.SourceFile
+	total  int
+}
+
+func (u *unpacker) add(name string, content io.Reader) error {
+	name = strings.TrimPrefix(name, "./")
+	if err := model.ValidateFileName(name); err != nil {
+		return err
+	}
+	if len(u.files) >= u.limits.maxFiles {
+		return i18n.Errorf("upload contains more than %d files", u.limits.maxFiles)
+	}
+
+	limit := min(u.limits.maxFileBytes, u.limits.maxTotal-u.total)
+	data, err := io.ReadAll(io.LimitReader(content, int64(limit)+1))
+	if err != nil {
+		return i18n.Errorf("failed to read '%s': %w", name, err)
+	}
+	if len(data) > limit {
+		return i18n.Errorf("file '%s' is too large once unpacked", name)
+	}
+
+	u.total += len(data)
+	u.files = append(u.files, model.SourceFile{Name: name, Code: string(data)})
+	return nil
+}
+
+func (u *unpacker) addZip(data []byte) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return i18n.Errorf("invalid zip archive: %w", err)
+	}
+	if len(archive.File) > maxArchiveEntries {
+		return i18n.Errorf("zip archive has more than %d entries", maxArchiveEntries)
+	}
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		if !entry.Mode().IsRegular() {
+			return i18n.Errorf("zip entry '%s' is not a regular file", entry.Name)
+		}
+		content, err := entry.Open()
+		if err != nil {
+			return i18n.Errorf("failed to open zip entry '%s': %w", entry.Name, err)
+		}
+		err = u.add(entry.Name, content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *unpacker) addTarGz(content io.Reader) error {
+	gz, err := gzip.NewReader(content)
+	if err != nil {
+		return i18n.Errorf("invalid gzip data: %w", err)
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for entries := 0; ; entries++ {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return i18n.Errorf("invalid tar archive: %w", err)
+		}
+		if entries >= maxArchiveEntries {
+			return i18n.Errorf("tar archive has more than %d entries", maxArchiveEntries)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+			if err := u.add(header.Name, archive); err != nil {
+				return err
+			}
+		default:
+			return i18n.Errorf("tar entry '%s' is not a regular file", header.Name)
+		}
+	}
+}
+
+// addArchive unpacks a zip or tar.gz archive, picked by its name.
+func (u *unpacker) addArchive(name string, content io.Reader) error {
+	if strings.HasSuffix(strings.ToLower(name), ".zip") {
+		// zip needs random access, the request size limit bounds this read
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return i18n.Errorf("failed to read '%s': %w", name, err)
+		}
+		return u.addZip(data)
+	}
+	return u.addTarGz(content)
+}
+
+// readUploadedSubmission reads the files of a multipart or archive upload.
+// Multipart uploads carry the 'UserId' as a form field and any number of
+// files, archives among them are unpacked. Bare archive uploads carry the
+// 'UserId' as a query parameter.
+func readUploadedSubmission(w http.ResponseWriter, r *http.Request, mediaType string) (map[string]interface{}, []model.SourceFile, error) {
+	limits := currentUploadLimits(r)
+	r.Body = http.MaxBytesReader(w, r.Body, int64(limits.maxTotal+uploadOverheadBytes))
+	u := &unpacker{limits: limits}
+
+	received := make(map[string]interface{})
+	parseUserId := func(value string) error {
+		id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if err != nil {
+			return i18n.Errorf("missing or invalid field 'UserId'")
+		}
+		received["UserId"] = float64(id)
+		return nil
+	}
+
+	if mediaType != "multipart/form-data" {
+		if err := parseUserId(r.URL.Query().Get("UserId")); err != nil {
+			return nil, nil, err
+		}
+		name := "upload.tar.gz"
+		if mediaType == "application/zip" {
+			name = "upload.zip"
+		}
+		if err := u.addArchive(name, r.Body); err != nil {
+			return nil, nil, err
+		}
+		return received, u.files, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, i18n.Errorf("invalid multipart upload: %w", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, i18n.Errorf("invalid multipart upload: %w", err)
+		}
+		err = readUploadPart(u, part, parseUserId)
+		part.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if _, ok := received["UserId"]; !ok {
+		return nil, nil, i18n.Errorf("missing or invalid field 'UserId'")
+	}
+	return received, u.files, nil
+}
+
+func readUploadPart(u *unpacker, part *multipart.Part, parseUserId func(string) error) error {
+	// part.FileName() drops directories, which the submission needs to keep
+	_, params, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+	fileName := params["filename"]
+
+	if fileName == "" {
+		if part.FormName() != "UserId" {
+			return nil
+		}
+		value, err := io.ReadAll(io.LimitReader(part, 64))
+		if err != nil {
+			return i18n.Errorf("invalid multipart upload: %w", err)
+		}
+		return parseUserId(string(value))
+	}
+
+	if isArchiveName(fileName) {
+		return u.addArchive(fileName, part)
+	}
+	return u.add(fileName, part)
+}
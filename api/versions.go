@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/i18n"
+	"server/model"
+	"time"
+)
+
+// decodeVersionRequest decodes a request about the versions of a submission.
+// Users may only look at their own versions, admins may pass 'TargetUser' to
+// look at anyone's. The caller must hold model.Mutex. On failure an error has
+// already been written to w and ok is false.
+func decodeVersionRequest(w http.ResponseWriter, r *http.Request) (received map[string]interface{}, ownerId int32, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+		httpError(w, r, http.StatusBadRequest, "Invalid JSON payload")
+		return nil, 0, false
+	}
+
+	auth, userId := model.IsAuthedRequest(received)
+	if !auth {
+		httpError(w, r, http.StatusUnauthorized, "Unauthorized")
+		return nil, 0, false
+	}
+
+	ownerId = userId
+	if targetUser, ok := received["TargetUser"].(string); ok {
+		if !model.IsAdmin(userId) {
+			httpError(w, r, http.StatusForbidden, "Forbidden: only admins may look at other users' versions")
+			return nil, 0, false
+		}
+		var found bool
+		ownerId, found = model.FindUserIdByName(targetUser)
+		if !found {
+			httpError(w, r, http.StatusBadRequest, "Invalid field 'TargetUser'")
+			return nil, 0, false
+		}
+	}
+
+	if _, ok := model.Submissions[ownerId]; !ok {
+		httpError(w, r, http.StatusBadRequest, "No submission found")
+		return nil, 0, false
+	}
+	return received, ownerId, true
+}
+
+// readVersion reads a version number from received[key]. The latest version is
+// used when the field is missing.
+func readVersion(received map[string]interface{}, key string, ownerId int32) (*model.SubmissionVersion, error) {
+	number := len(model.Submissions[ownerId].Versions)
+	if raw, ok := received[key]; ok {
+		value, ok := raw.(float64)
+		if !ok {
+			return nil, i18n.Errorf("Invalid field '%s'", key)
+		}
+		number = int(value)
+	}
+	return model.GetSubmissionVersion(ownerId, number)
+}
+
+type publicSubmissionVersion struct {
+	Number  int
+	Time    time.Time
+	Hash    string
+	Files   []string
+	Counted bool // whether this version counts for grading
+}
+
+// RouteGET_GetSubmissionVersions lists every version of the user's submission this round
+func RouteGET_GetSubmissionVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	_, ownerId, ok := decodeVersionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	counted := model.CountedVersion(ownerId)
+	versions := make([]publicSubmissionVersion, 0)
+	for _, version := range model.Submissions[ownerId].Versions {
+		var entry publicSubmissionVersion
+		entry.Number = version.Number
+		entry.Time = version.Time
+		entry.Hash = version.Hash
+		entry.Files = make([]string, 0, len(version.Source))
+		for _, file := range version.Source {
+			entry.Files = append(entry.Files, file.Name)
+		}
+		entry.Counted = version.Number == counted
+		versions = append(versions, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// RouteGET_GetSubmissionVersion returns the files of one version of the user's submission
+func RouteGET_GetSubmissionVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	received, ownerId, ok := decodeVersionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	version, err := readVersion(received, "Version", ownerId)
+	if err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version)
+}
+
+// RouteGET_SubmissionDiff returns a unified diff between two versions of the user's submission
+func RouteGET_SubmissionDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected GET")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	received, ownerId, ok := decodeVersionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	from, err := readVersion(received, "From", ownerId)
+	if err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+	to, err := readVersion(received, "To", ownerId)
+	if err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"From": from.Number,
+		"To":   to.Number,
+		"Diff": model.DiffSourceFiles(from.Source, to.Source),
+	})
+}
+
+// RoutePOST_AdminSelectSubmissionVersion chooses which version of a submission counts for grading
+func RoutePOST_AdminSelectSubmissionVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpError(w, r, http.StatusMethodNotAllowed, "Method not allowed: Expected POST")
+		return
+	}
+
+	lockRoom(r)
+	defer model.Mutex.Unlock()
+
+	received, _, ok := decodeAdminRequest(w, r)
+	if !ok {
+		return
+	}
+
+	targetUser, ok := received["TargetUser"].(string)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'TargetUser'")
+		return
+	}
+	targetUserId, found := model.FindUserIdByName(targetUser)
+	if !found {
+		httpError(w, r, http.StatusBadRequest, "Invalid field 'TargetUser'")
+		return
+	}
+
+	number, ok := received["Version"].(float64)
+	if !ok {
+		httpError(w, r, http.StatusBadRequest, "Missing or invalid field 'Version'")
+		return
+	}
+
+	if err := model.SelectSubmissionVersion(targetUserId, int(number)); err != nil {
+		httpErrorFrom(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Error\":\"Success\"}"))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"server/kattis"
+	"server/server"
+)
+
+const commandsUsage = `Usage:
+  server                                          run the server
+  server import-kattis <package dir> <pack dir>   convert a Kattis problem package into a problem pack
+  server export-kattis <pack> <package dir>       convert a problem pack, directory or zip, into a Kattis problem package`
+
+func printWarnings(warnings []string) {
+	for _, warning := range warnings {
+		fmt.Println("Warning:", warning)
+	}
+}
+
+func importKattis(packageDir string, packDir string) error {
+	problem, warnings, err := kattis.Import(os.DirFS(packageDir), filepath.Base(filepath.Clean(packageDir)))
+	if err != nil {
+		return err
+	}
+	printWarnings(warnings)
+	if err = server.WritePack(problem, packDir); err != nil {
+		return err
+	}
+	fmt.Println("Imported", problem.Header.Name, "into", packDir)
+	return nil
+}
+
+func exportKattis(pack string, packageDir string) error {
+	problem, err := server.LoadPackPath(pack)
+	if err != nil {
+		return err
+	}
+	warnings, err := kattis.Export(problem, packageDir)
+	if err != nil {
+		return err
+	}
+	printWarnings(warnings)
+	fmt.Println("Exported", problem.Header.Name, "to", packageDir)
+	return nil
+}
+
+// runCommand runs the subcommand in args, if any, and exits. Returns when the
+// server should be started instead.
+func runCommand(args []string) {
+	if len(args) == 0 {
+		return
+	}
+
+	var err error
+	switch {
+	case args[0] == "import-kattis" && len(args) == 3:
+		err = importKattis(args[1], args[2])
+	case args[0] == "export-kattis" && len(args) == 3:
+		err = exportKattis(args[1], args[2])
+	default:
+		fmt.Println(commandsUsage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,135 @@
+// Package i18n translates the server's messages. Messages are written in
+// English in the code and looked up by that text in a catalog of
+// translations, one JSON file per language in messages/.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is the language messages are written in.
+const DefaultLanguage = "en"
+
+//go:embed messages/*.json
+var messageFiles embed.FS
+
+var catalog = loadCatalog() // LOOKUP BY LANGUAGE, THEN BY ENGLISH FORMAT
+
+func loadCatalog() map[string]map[string]string {
+	catalog := make(map[string]map[string]string)
+	files, _ := messageFiles.ReadDir("messages")
+	for _, file := range files {
+		data, err := messageFiles.ReadFile("messages/" + file.Name())
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err = json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", file.Name(), err))
+		}
+		catalog[strings.TrimSuffix(file.Name(), path.Ext(file.Name()))] = messages
+	}
+	return catalog
+}
+
+// Languages lists the languages messages can be translated to, the default first.
+func Languages() []string {
+	languages := []string{DefaultLanguage}
+	for language := range catalog {
+		languages = append(languages, language)
+	}
+	slices.Sort(languages[1:])
+	return languages
+}
+
+// Sprintf formats the translation of format to language, or format itself if
+// it has none. Like fmt.Errorf, %w formats an error.
+func Sprintf(language string, format string, args ...interface{}) string {
+	if translated, ok := catalog[language][format]; ok {
+		format = translated
+	}
+	localized := make([]interface{}, len(args))
+	for i, arg := range args {
+		localized[i] = arg
+		if message, ok := arg.(*Message); ok {
+			localized[i] = errors.New(Sprintf(language, message.Format, message.Args...))
+		}
+	}
+	return fmt.Errorf(format, localized...).Error()
+}
+
+// Message is an error whose text can be translated.
+type Message struct {
+	Format string
+	Args   []interface{}
+}
+
+func (m *Message) Error() string {
+	return Sprintf(DefaultLanguage, m.Format, m.Args...)
+}
+
+// Errorf returns a translatable error, formatted like fmt.Errorf.
+func Errorf(format string, args ...interface{}) error {
+	return &Message{format, args}
+}
+
+// Localize returns the text of err in language. Only errors made by Errorf
+// are translated.
+func Localize(language string, err error) string {
+	if message, ok := err.(*Message); ok {
+		return Sprintf(language, message.Format, message.Args...)
+	}
+	return err.Error()
+}
+
+// Match picks the best of the supported languages for an Accept-Language
+// header, like "fr-CH, fr;q=0.9, en;q=0.8". Regional variants fall back to
+// their base language. Returns "" if none is acceptable.
+func Match(acceptLanguage string, supported []string) string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		if tag != "" && quality > 0 {
+			tags = append(tags, weighted{strings.ToLower(strings.TrimSpace(tag)), quality})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+
+	for _, tag := range tags {
+		if tag.tag == "*" && len(supported) > 0 {
+			return supported[0]
+		}
+		base, _, _ := strings.Cut(tag.tag, "-")
+		for _, candidate := range []string{tag.tag, base} {
+			for _, language := range supported {
+				if strings.ToLower(language) == candidate {
+					return language
+				}
+			}
+		}
+		for _, language := range supported {
+			if languageBase, _, _ := strings.Cut(strings.ToLower(language), "-"); languageBase == base {
+				return language
+			}
+		}
+	}
+	return ""
+}
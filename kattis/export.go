@@ -0,0 +1,150 @@
+package kattis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"server/model"
+	"strings"
+)
+
+func writeFile(dir string, name string, data string) error {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, []byte(data), 0o644)
+}
+
+// testCaseFiles returns the .in and .ans contents of a test case. JSON test
+// cases are written as their JSON text.
+func testCaseFiles(testCase *model.TestCase) (input string, answer string, err error) {
+	if err = testCase.LoadData(); err != nil {
+		return "", "", err
+	}
+	if testCase.Mode == model.TextMode {
+		return testCase.Input, testCase.OutputText, nil
+	}
+	output, err := json.Marshal(testCase.OutputJSON)
+	if err != nil {
+		return "", "", err
+	}
+	return testCase.Input + "\n", string(output) + "\n", nil
+}
+
+// validatorFlags returns the default output validator's flags matching how
+// the problem's test cases compare outputs. Kattis only has one setting per
+// problem, so the first test case's is used.
+func validatorFlags(problem *model.Problem) (flags []string, warnings []string) {
+	first := problem.TestCases[0]
+	for _, testCase := range problem.TestCases[1:] {
+		if testCase.CaseSensitive != first.CaseSensitive || testCase.Mode != first.Mode || testCase.Comparison != first.Comparison {
+			warnings = append(warnings, "test cases compare outputs differently, all use the first test case's comparison")
+			break
+		}
+	}
+
+	if first.CaseSensitive {
+		flags = append(flags, "case_sensitive")
+	}
+	if first.Mode == model.TextMode && first.Comparison == model.ExactComparison {
+		flags = append(flags, "space_change_sensitive")
+	}
+	if first.Mode == model.JSONMode {
+		warnings = append(warnings, "JSON test cases are compared by tokens of their JSON text")
+	}
+	return flags, warnings
+}
+
+// Export writes problem as a Kattis problem package into dir. Warnings list
+// what the conversion could not keep.
+func Export(problem *model.Problem, dir string) (warnings []string, err error) {
+	if len(problem.TestCases) == 0 {
+		return nil, fmt.Errorf("problem '%s' has no test cases", problem.Header.Name)
+	}
+
+	var yaml strings.Builder
+	yaml.WriteString("name: " + quoteYAML(problem.Header.Name) + "\n")
+	if problem.Source != "" {
+		yaml.WriteString("source: " + quoteYAML(problem.Source) + "\n")
+	}
+	if len(problem.Checker) > 0 {
+		yaml.WriteString("validation: custom\n")
+	} else {
+		yaml.WriteString("validation: default\n")
+	}
+	flags, flagWarnings := validatorFlags(problem)
+	warnings = append(warnings, flagWarnings...)
+	if len(flags) > 0 {
+		yaml.WriteString("validator_flags: " + strings.Join(flags, " ") + "\n")
+	}
+	if err = writeFile(dir, "problem.yaml", yaml.String()); err != nil {
+		return nil, err
+	}
+
+	statement := problem.Statement
+	if statement == "" {
+		statement = problem.Header.Description + "\n"
+	}
+	language := problem.Language
+	if language == "" {
+		language = "en"
+	}
+	if err = writeFile(dir, "problem_statement/problem."+language+".md", statement); err != nil {
+		return nil, err
+	}
+	for language, translation := range problem.Translations {
+		if err = writeFile(dir, "problem_statement/problem."+language+".md", translation.Statement); err != nil {
+			return nil, err
+		}
+	}
+	for src, data := range problem.Images {
+		if err = writeFile(dir, "problem_statement/"+path.Clean(src), string(data)); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range problem.TestCases {
+		testCase := &problem.TestCases[i]
+		input, answer, err := testCaseFiles(testCase)
+		if err != nil {
+			return nil, err
+		}
+		group := "secret/"
+		if testCase.Sample {
+			group = "sample/"
+		}
+		// imported test cases keep their name, and with it their test group
+		name := fmt.Sprintf("data/%s%03d", group, i+1)
+		if strings.HasPrefix(testCase.Name, group) {
+			name = "data/" + testCase.Name
+		}
+		if err = writeFile(dir, name+".in", input); err != nil {
+			return nil, err
+		}
+		if err = writeFile(dir, name+".ans", answer); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range problem.Checker {
+		if err = writeFile(dir, "output_validators/"+file.Name, file.Code); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range problem.Solution {
+		if err = writeFile(dir, "submissions/accepted/"+file.Name, file.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(problem.Rubric) > 0 {
+		warnings = append(warnings, "the rubric was dropped, Kattis packages have none")
+	}
+	if len(problem.Starters) > 0 {
+		warnings = append(warnings, "the starter templates were dropped, Kattis packages have none")
+	}
+	return warnings, nil
+}
@@ -0,0 +1,214 @@
+// Package kattis converts between problems and the Kattis/ICPC problem
+// package format: problem.yaml, a statement, data/sample and data/secret test
+// data, output validators and accepted submissions.
+package kattis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"server/markdown"
+	"server/model"
+	"slices"
+	"strings"
+)
+
+// statementFiles are the places a package's statement may be, most preferred first.
+var statementFiles = []string{
+	"problem_statement/problem.en.md",
+	"problem_statement/problem.md",
+	"statement/problem.en.md",
+	"problem_statement/problem.en.tex",
+	"problem_statement/problem.tex",
+	"statement/problem.en.tex",
+}
+
+// readTree reads every file under dir, with names relative to dir. Returns nil
+// if dir doesn't exist.
+func readTree(pkg fs.FS, dir string) ([]model.SourceFile, error) {
+	if _, err := fs.Stat(pkg, dir); err != nil {
+		return nil, nil
+	}
+
+	var files []model.SourceFile
+	err := fs.WalkDir(pkg, dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		code, err := fs.ReadFile(pkg, name)
+		if err != nil {
+			return err
+		}
+		files = append(files, model.SourceFile{Name: strings.TrimPrefix(name, dir+"/"), Code: string(code)})
+		return nil
+	})
+	return files, err
+}
+
+func dataFile(pkg fs.FS, name string) (*model.TestDataFile, error) {
+	data, err := fs.ReadFile(pkg, name)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &model.TestDataFile{FS: pkg, Path: name, SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// readTestCases reads the .in/.ans pairs under dir, test groups in
+// subdirectories included, in the order Kattis runs them.
+func readTestCases(pkg fs.FS, dir string, testCase model.TestCase) ([]model.TestCase, error) {
+	if _, err := fs.Stat(pkg, dir); err != nil {
+		return nil, nil
+	}
+
+	var inputs []string
+	err := fs.WalkDir(pkg, dir, func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && strings.HasSuffix(name, ".in") {
+			inputs = append(inputs, name)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(inputs)
+
+	var testCases []model.TestCase
+	for _, input := range inputs {
+		answer := strings.TrimSuffix(input, ".in") + ".ans"
+		if _, err = fs.Stat(pkg, answer); err != nil {
+			return nil, fmt.Errorf("test data '%s' has no '%s'", input, path.Base(answer))
+		}
+		testCase.InputFile, err = dataFile(pkg, input)
+		if err != nil {
+			return nil, err
+		}
+		testCase.OutputFile, err = dataFile(pkg, answer)
+		if err != nil {
+			return nil, err
+		}
+		testCase.Name = strings.TrimSuffix(strings.TrimPrefix(input, "data/"), ".in")
+		testCases = append(testCases, testCase)
+	}
+	return testCases, nil
+}
+
+// readImages reads the images a statement embeds, relative to dir.
+func readImages(pkg fs.FS, dir string, statement string) map[string][]byte {
+	images := make(map[string][]byte)
+	for _, src := range markdown.ImageSources(statement) {
+		if !fs.ValidPath(path.Clean(src)) {
+			continue // absolute URLs and files outside the statement's directory
+		}
+		if data, err := fs.ReadFile(pkg, path.Join(dir, src)); err == nil {
+			images[src] = data
+		}
+	}
+	return images
+}
+
+// Import converts the Kattis problem package at the root of pkg. Test data
+// stays in pkg and is read when needed. Warnings list what the conversion
+// could not keep.
+func Import(pkg fs.FS, name string) (problem *model.Problem, warnings []string, err error) {
+	yamlText, err := fs.ReadFile(pkg, "problem.yaml")
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a Kattis problem package: %w", err)
+	}
+	config, err := parseYAML(string(yamlText))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	problem = &model.Problem{Difficulty: model.Medium, Images: make(map[string][]byte)}
+	problem.Header.Name = yamlString(config, "name")
+	if problem.Header.Name == "" {
+		problem.Header.Name = name
+	}
+	problem.Header.Description = problem.Header.Name
+	problem.Source = yamlString(config, "source")
+	if problem.Source != "" {
+		problem.Header.Description = "From " + problem.Source
+	}
+
+	for _, statementFile := range statementFiles {
+		if statement, err := fs.ReadFile(pkg, statementFile); err == nil {
+			problem.Statement = string(statement)
+			if path.Ext(statementFile) == ".tex" {
+				warnings = append(warnings, "the statement is LaTeX and was kept as-is")
+			}
+			problem.Images = readImages(pkg, path.Dir(statementFile), problem.Statement)
+			break
+		}
+	}
+	if problem.Statement == "" {
+		warnings = append(warnings, "the package has no statement")
+	}
+
+	// other languages' statements, the English one is the main statement
+	problem.Language = "en"
+	problem.Translations = make(map[string]model.Translation)
+	for _, dir := range []string{"problem_statement", "statement"} {
+		translations, _ := fs.Glob(pkg, dir+"/problem.*.md")
+		for _, name := range translations {
+			language := strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "problem."), ".md")
+			if _, ok := problem.Translations[language]; ok || language == "en" || model.ValidateLanguageTag(language) != nil {
+				continue
+			}
+			statement, err := fs.ReadFile(pkg, name)
+			if err != nil {
+				return nil, nil, err
+			}
+			problem.Translations[language] = model.Translation{Statement: string(statement)}
+			for src, data := range readImages(pkg, dir, string(statement)) {
+				problem.Images[src] = data
+			}
+		}
+	}
+
+	// the default output validator compares tokens, case-insensitively unless told otherwise
+	testCase := model.TestCase{Mode: model.TextMode, Comparison: model.TokenComparison, External: true}
+	for _, flag := range strings.Fields(yamlString(config, "validator_flags")) {
+		switch flag {
+		case "case_sensitive":
+			testCase.CaseSensitive = true
+		case "space_change_sensitive":
+			testCase.Comparison = model.ExactComparison
+		default:
+			warnings = append(warnings, "unsupported validator flag '"+flag+"' was ignored")
+		}
+	}
+
+	testCase.Sample = true
+	samples, err := readTestCases(pkg, "data/sample", testCase)
+	if err != nil {
+		return nil, nil, err
+	}
+	testCase.Sample = false
+	secrets, err := readTestCases(pkg, "data/secret", testCase)
+	if err != nil {
+		return nil, nil, err
+	}
+	problem.TestCases = append(samples, secrets...)
+	if len(problem.TestCases) == 0 {
+		return nil, nil, fmt.Errorf("the package has no test data")
+	}
+
+	// with default validation Kattis doesn't run the package's output validators either
+	validators, err := readTree(pkg, "output_validators")
+	if err != nil {
+		return nil, nil, err
+	}
+	if strings.HasPrefix(yamlString(config, "validation"), "custom") {
+		problem.Checker = validators
+		warnings = append(warnings, "the custom output validator was kept as the checker, the server compares outputs by tokens")
+	} else if len(validators) > 0 {
+		warnings = append(warnings, "the output validators were dropped, the package uses default validation")
+	}
+	if problem.Solution, err = readTree(pkg, "submissions/accepted"); err != nil {
+		return nil, nil, err
+	}
+	return problem, warnings, nil
+}
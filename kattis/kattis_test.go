@@ -0,0 +1,130 @@
+package kattis
+
+import (
+	"os"
+	"path/filepath"
+	"server/model"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func importFixture(t *testing.T) *model.Problem {
+	t.Helper()
+	problem, _, err := Import(os.DirFS("testdata/addition"), "addition")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	return problem
+}
+
+// roundTrip exports problem and imports the package again.
+func roundTrip(t *testing.T, problem *model.Problem) (*model.Problem, map[string]interface{}) {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := Export(problem, dir); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	yamlText, err := os.ReadFile(filepath.Join(dir, "problem.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := parseYAML(string(yamlText))
+	if err != nil {
+		t.Fatalf("exported problem.yaml: %v", err)
+	}
+	again, _, err := Import(os.DirFS(dir), "addition")
+	if err != nil {
+		t.Fatalf("import of the export: %v", err)
+	}
+	return again, config
+}
+
+func TestImport(t *testing.T) {
+	problem := importFixture(t)
+
+	if problem.Header.Name != "Addition" {
+		t.Errorf("name = %q, want %q", problem.Header.Name, "Addition")
+	}
+	if problem.Source != "Example Contest: Warmup" {
+		t.Errorf("source = %q, want %q", problem.Source, "Example Contest: Warmup")
+	}
+	if len(problem.Checker) != 0 {
+		t.Errorf("the validators of a package with default validation were kept as the checker")
+	}
+	if len(problem.Solution) != 1 {
+		t.Errorf("%d accepted submissions, want 1", len(problem.Solution))
+	}
+
+	var names []string
+	for _, testCase := range problem.TestCases {
+		names = append(names, testCase.Name)
+		if !testCase.CaseSensitive || testCase.Comparison != model.TokenComparison {
+			t.Errorf("test case %q doesn't compare like validator_flags say", testCase.Name)
+		}
+		if testCase.Sample != strings.HasPrefix(testCase.Name, "sample/") {
+			t.Errorf("test case %q has Sample %v", testCase.Name, testCase.Sample)
+		}
+	}
+	want := []string{"sample/1", "sample/2", "secret/group1/big", "secret/negative"}
+	if !slices.Equal(names, want) {
+		t.Errorf("test cases = %v, want %v", names, want)
+	}
+}
+
+func TestRoundTripKeepsPackage(t *testing.T) {
+	problem := importFixture(t)
+	again, config := roundTrip(t, problem)
+
+	if validation := yamlString(config, "validation"); validation != "default" {
+		t.Errorf("validation = %q, want %q", validation, "default")
+	}
+	if flags := yamlString(config, "validator_flags"); flags != "case_sensitive" {
+		t.Errorf("validator_flags = %q, want %q", flags, "case_sensitive")
+	}
+	if source := yamlString(config, "source"); source != problem.Source {
+		t.Errorf("source = %q, want %q", source, problem.Source)
+	}
+
+	if again.Header.Name != problem.Header.Name || again.Source != problem.Source {
+		t.Errorf("got %q from %q, want %q from %q", again.Header.Name, again.Source, problem.Header.Name, problem.Source)
+	}
+	if again.Statement != problem.Statement {
+		t.Errorf("the statement changed")
+	}
+	if len(again.Solution) != len(problem.Solution) {
+		t.Errorf("%d accepted submissions, want %d", len(again.Solution), len(problem.Solution))
+	}
+
+	if len(again.TestCases) != len(problem.TestCases) {
+		t.Fatalf("%d test cases, want %d", len(again.TestCases), len(problem.TestCases))
+	}
+	for i := range problem.TestCases {
+		before, after := &problem.TestCases[i], &again.TestCases[i]
+		if after.Name != before.Name || after.Sample != before.Sample {
+			t.Errorf("test case %d is %q (sample %v), want %q (sample %v)", i, after.Name, after.Sample, before.Name, before.Sample)
+		}
+		if err := before.LoadData(); err != nil {
+			t.Fatal(err)
+		}
+		if err := after.LoadData(); err != nil {
+			t.Fatal(err)
+		}
+		if after.Input != before.Input || after.OutputText != before.OutputText {
+			t.Errorf("the data of test case %q changed", before.Name)
+		}
+	}
+}
+
+func TestRoundTripKeepsCustomValidation(t *testing.T) {
+	problem := importFixture(t)
+	problem.Checker = []model.SourceFile{{Name: "checker/validate.py", Code: "print('ok')\n"}}
+	again, config := roundTrip(t, problem)
+
+	if validation := yamlString(config, "validation"); validation != "custom" {
+		t.Errorf("validation = %q, want %q", validation, "custom")
+	}
+	if len(again.Checker) != 1 || again.Checker[0] != problem.Checker[0] {
+		t.Errorf("checker = %v, want %v", again.Checker, problem.Checker)
+	}
+}
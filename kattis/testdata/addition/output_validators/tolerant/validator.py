@@ -0,0 +1,8 @@
+import sys
+
+# Kattis output validator: reads the judge answer from argv[2] and the
+# contestant's output from stdin, exits 42 to accept and 43 to reject.
+with open(sys.argv[2]) as answer_file:
+    answer = answer_file.read().split()
+output = sys.stdin.read().split()
+sys.exit(42 if output == answer else 43)
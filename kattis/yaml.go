@@ -0,0 +1,109 @@
+package kattis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseYAML reads the small subset of YAML that problem.yaml files use:
+// "key: value" pairs, nested by indentation, with comments. Values are
+// strings or nested maps, lists are kept as their raw text.
+func parseYAML(text string) (map[string]interface{}, error) {
+	type level struct {
+		indent int
+		values map[string]interface{}
+	}
+	root := make(map[string]interface{})
+	stack := []level{{-1, root}}
+	var pendingKey string // key waiting for a nested block
+
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		content := stripYAMLComment(line)
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		indent := len(content) - len(strings.TrimLeft(content, " "))
+		content = strings.TrimSpace(content)
+
+		if pendingKey != "" {
+			parent := stack[len(stack)-1]
+			if indent > parent.indent {
+				nested := make(map[string]interface{})
+				parent.values[pendingKey] = nested
+				stack = append(stack, level{indent, nested})
+			}
+			pendingKey = ""
+		}
+		for len(stack) > 1 && indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		current := stack[len(stack)-1].values
+
+		if strings.HasPrefix(content, "- ") || content == "-" {
+			continue // list items aren't needed
+		}
+		key, value, found := strings.Cut(content, ":")
+		if !found {
+			return nil, fmt.Errorf("problem.yaml line %d: expected 'key: value'", i+1)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if value == "" {
+			current[key] = ""
+			pendingKey = key
+			continue
+		}
+		current[key] = unquoteYAML(value)
+	}
+	return root, nil
+}
+
+// stripYAMLComment removes a trailing # comment that isn't inside quotes.
+func stripYAMLComment(line string) string {
+	var quote rune
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquoteYAML(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		if value[0] == '\'' {
+			return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "\\\"", "\"")
+	}
+	return value
+}
+
+// yamlString returns the string value of key, or its "en" entry if the value
+// is a map of translations.
+func yamlString(values map[string]interface{}, key string) string {
+	switch value := values[key].(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		if en, ok := value["en"].(string); ok {
+			return en
+		}
+	}
+	return ""
+}
+
+// quoteYAML quotes a value if writing it bare could change its meaning.
+func quoteYAML(value string) string {
+	if value == "" || strings.ContainsAny(value, ":#'\"{}[]&*!|>%@`,") || strings.TrimSpace(value) != value {
+		return "\"" + strings.ReplaceAll(value, "\"", "\\\"") + "\""
+	}
+	return value
+}
@@ -1,19 +1,34 @@
 package main
 
 import (
+	"embed"
 	"fmt"
+	"io/fs"
+	"os"
 	"server/model"
 	"server/server"
+	"time"
 )
 
 var port uint16 = 3000
 
+// how often the problems directory is checked for changes
+const problemsPollInterval = 2 * time.Second
+
+// the problems shipped with the binary, used when there is no problems directory
+//
+//go:embed problems
+var embeddedProblems embed.FS
+
 func main() {
+	runCommand(os.Args[1:])
+
 	model.Init()
 
-	err := server.InitProblems("problems")
+	defaultProblems, _ := fs.Sub(embeddedProblems, "problems")
+	err := server.InitRooms("rooms", defaultProblems, problemsPollInterval)
 	if err != nil {
-		fmt.Println("Error parsing JSON problems file.")
+		fmt.Println("Error loading the rooms:", err)
 		return
 	}
 
@@ -22,14 +37,14 @@ func main() {
 		fmt.Println("Error, closing server.")
 		return
 	}
+	defer server.Terminate()
 
 	fmt.Println("Server open on port", port, ".\nPress any ctrl+C to quit the server.")
+	fmt.Println("Admin key:", model.AdminKey)
 
 	for {
 		model.Mutex.Lock()
-		model.Tick()
+		model.TickRooms()
 		model.Mutex.Unlock()
 	}
-
-	server.Terminate()
 }
@@ -0,0 +1,296 @@
+// Package markdown renders the subset of Markdown used by problem statements
+// to HTML. Raw HTML in the source is always escaped and only safe link and
+// image URLs are kept, so the output can be embedded in a page as-is.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ImageResolver maps the source of an image to the URL it is served from.
+// Returning false drops the image, keeping its alt text.
+type ImageResolver func(src string) (string, bool)
+
+var headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+var orderedItemPattern = regexp.MustCompile(`^\d{1,9}[.)]\s+`)
+var unorderedItemPattern = regexp.MustCompile(`^[-*+]\s+`)
+var rulePattern = regexp.MustCompile(`^(-\s*){3,}$|^(\*\s*){3,}$|^(_\s*){3,}$`)
+var imagePattern = regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+var codeLanguagePattern = regexp.MustCompile(`^[A-Za-z0-9_+-]+$`)
+
+type renderer struct {
+	out     strings.Builder
+	resolve ImageResolver
+}
+
+// ToHTML renders Markdown source to sanitized HTML. resolve may be nil to keep
+// only absolute image URLs.
+func ToHTML(source string, resolve ImageResolver) string {
+	r := &renderer{resolve: resolve}
+	r.blocks(strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n"))
+	return r.out.String()
+}
+
+// ImageSources lists the sources of every image in Markdown source.
+func ImageSources(source string) []string {
+	var sources []string
+	for _, match := range imagePattern.FindAllStringSubmatch(source, -1) {
+		sources = append(sources, match[1])
+	}
+	return sources
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+func isListItem(line string) bool {
+	return unorderedItemPattern.MatchString(line) || orderedItemPattern.MatchString(line)
+}
+
+// startsBlock reports whether line interrupts a paragraph.
+func startsBlock(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || isFence(line) || headingPattern.MatchString(trimmed) ||
+		strings.HasPrefix(trimmed, ">") || rulePattern.MatchString(trimmed) || isListItem(trimmed)
+}
+
+func (r *renderer) blocks(lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			i++
+
+		case isFence(line):
+			language := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			i++
+			var code []string
+			for i < len(lines) && !isFence(lines[i]) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // closing fence
+			if codeLanguagePattern.MatchString(language) {
+				r.out.WriteString("<pre><code class=\"language-" + language + "\">")
+			} else {
+				r.out.WriteString("<pre><code>")
+			}
+			for _, codeLine := range code {
+				r.out.WriteString(html.EscapeString(codeLine) + "\n")
+			}
+			r.out.WriteString("</code></pre>\n")
+
+		case headingPattern.MatchString(trimmed):
+			match := headingPattern.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(match[1])))
+			r.out.WriteString("<h" + level + ">" + r.inline(match[2]) + "</h" + level + ">\n")
+			i++
+
+		case rulePattern.MatchString(trimmed):
+			r.out.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			var quoted []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				quoted = append(quoted, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"), " "))
+				i++
+			}
+			r.out.WriteString("<blockquote>\n")
+			r.blocks(quoted)
+			r.out.WriteString("</blockquote>\n")
+
+		case isListItem(trimmed):
+			i = r.list(lines, i)
+
+		default:
+			var paragraph []string
+			for i < len(lines) && (len(paragraph) == 0 || !startsBlock(lines[i])) {
+				paragraph = append(paragraph, strings.TrimSpace(lines[i]))
+				i++
+			}
+			r.out.WriteString("<p>" + r.inline(strings.Join(paragraph, "\n")) + "</p>\n")
+		}
+	}
+}
+
+// list renders the list starting at lines[i] and returns the index after it.
+// Indented lines continue the previous item.
+func (r *renderer) list(lines []string, i int) int {
+	ordered := orderedItemPattern.MatchString(strings.TrimSpace(lines[i]))
+	pattern := unorderedItemPattern
+	tag := "ul"
+	if ordered {
+		pattern = orderedItemPattern
+		tag = "ol"
+	}
+
+	r.out.WriteString("<" + tag + ">\n")
+	for i < len(lines) {
+		trimmed := strings.TrimSpace(lines[i])
+		if !pattern.MatchString(trimmed) {
+			break
+		}
+		item := []string{pattern.ReplaceAllString(trimmed, "")}
+		i++
+		for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !isListItem(strings.TrimSpace(lines[i])) &&
+			(strings.HasPrefix(lines[i], " ") || strings.HasPrefix(lines[i], "\t") || !startsBlock(lines[i])) {
+			item = append(item, strings.TrimSpace(lines[i]))
+			i++
+		}
+		r.out.WriteString("<li>" + r.inline(strings.Join(item, "\n")) + "</li>\n")
+	}
+	r.out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// safeURL returns the URL if following it can't run script: relative URLs,
+// fragments and http, https and mailto URLs.
+func safeURL(raw string) (string, bool) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "", "http", "https", "mailto":
+		return parsed.String(), true
+	}
+	return "", false
+}
+
+// linkEnd finds the "[text](target)" at the start of text and returns the
+// text, the target and the length of the whole link.
+func linkEnd(text string) (label string, target string, length int, ok bool) {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				if i+1 >= len(text) || text[i+1] != '(' {
+					return "", "", 0, false
+				}
+				end := closingParen(text[i+1:])
+				if end < 0 {
+					return "", "", 0, false
+				}
+				target = strings.TrimSpace(text[i+2 : i+1+end])
+				if title := strings.Index(target, " \""); title >= 0 {
+					target = target[:title]
+				}
+				return text[1:i], target, i + 2 + end, true
+			}
+		}
+	}
+	return "", "", 0, false
+}
+
+// closingParen returns the index of the ')' matching the '(' text starts with, or -1.
+func closingParen(text string) int {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// inline renders the spans of a block: code, emphasis, links and images.
+func (r *renderer) inline(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte("\\`*_{}[]()#+-.!>|$", text[i+1]) >= 0:
+			out.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			ticks := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			delimiter := strings.Repeat("`", ticks)
+			if end := strings.Index(text[i+ticks:], delimiter); end >= 0 {
+				code := strings.TrimSpace(text[i+ticks : i+ticks+end])
+				out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += 2*ticks + end
+				continue
+			}
+
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if alt, src, length, ok := linkEnd(text[i+1:]); ok {
+				if resolved, ok := r.image(src); ok {
+					out.WriteString("<img src=\"" + html.EscapeString(resolved) + "\" alt=\"" + html.EscapeString(alt) + "\">")
+				} else {
+					out.WriteString(html.EscapeString(alt))
+				}
+				i += 1 + length
+				continue
+			}
+
+		case c == '[':
+			if label, target, length, ok := linkEnd(text[i:]); ok {
+				if href, ok := safeURL(target); ok {
+					out.WriteString("<a href=\"" + html.EscapeString(href) + "\" rel=\"nofollow noopener\">" + r.inline(label) + "</a>")
+				} else {
+					out.WriteString(r.inline(label))
+				}
+				i += length
+				continue
+			}
+
+		case c == '*' || c == '_':
+			delimiter := string(c)
+			tag := "em"
+			if strings.HasPrefix(text[i:], delimiter+delimiter) {
+				delimiter += delimiter
+				tag = "strong"
+			}
+			rest := text[i+len(delimiter):]
+			// _ inside words, like snake_case, isn't emphasis
+			intraword := c == '_' && i > 0 && isWordByte(text[i-1])
+			if end := strings.Index(rest, delimiter); end > 0 && !intraword && rest[0] != ' ' && rest[end-1] != ' ' {
+				out.WriteString("<" + tag + ">" + r.inline(rest[:end]) + "</" + tag + ">")
+				i += 2*len(delimiter) + end
+				continue
+			}
+		}
+
+		out.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+	return out.String()
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func (r *renderer) image(src string) (string, bool) {
+	parsed, err := url.Parse(src)
+	if err != nil {
+		return "", false
+	}
+	if scheme := strings.ToLower(parsed.Scheme); scheme == "http" || scheme == "https" {
+		return parsed.String(), true
+	}
+	if parsed.Scheme != "" || r.resolve == nil {
+		return "", false
+	}
+	return r.resolve(src)
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"math/rand/v2"
+	"sort"
+)
+
+// usersConflict reports whether reviewer may not review author's code: nobody
+// reviews themselves, and members of the same team don't review each other.
+func usersConflict(reviewerId int32, authorId int32) bool {
+	if reviewerId == authorId {
+		return true
+	}
+	reviewer, ok := Users[reviewerId]
+	if !ok {
+		return true
+	}
+	author, ok := Users[authorId]
+	if !ok {
+		return true
+	}
+	return reviewer.Team != "" && reviewer.Team == author.Team
+}
+
+// AssignReviews gives every user with a submission in the current round up to
+// Settings.ReviewsPerUser submissions to review. Targets are chosen least
+// reviewed first, so each submission ends up with a similar number of reviewers.
+func AssignReviews() {
+	ReviewAssignments = make(map[int32][]int32)
+
+	var submitters []int32
+	for uId, sub := range Submissions {
+		if len(sub.Source) == 0 || !IsValidUserId(uId) {
+			continue
+		}
+		submitters = append(submitters, uId)
+	}
+
+	// map order is not a fair shuffle, so sort first and then shuffle explicitly
+	sort.Slice(submitters, func(i, j int) bool { return submitters[i] < submitters[j] })
+	rand.Shuffle(len(submitters), func(i, j int) {
+		submitters[i], submitters[j] = submitters[j], submitters[i]
+	})
+
+	// candidates are listed starting after the reviewer's own position, so
+	// without conflicts this is a plain rotation and every load comes out equal
+	load := make(map[int32]int)
+	for pos, reviewerId := range submitters {
+		var candidates []int32
+		for offset := 1; offset < len(submitters); offset++ {
+			authorId := submitters[(pos+offset)%len(submitters)]
+			if !usersConflict(reviewerId, authorId) {
+				candidates = append(candidates, authorId)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return load[candidates[i]] < load[candidates[j]]
+		})
+
+		count := min(Settings.ReviewsPerUser, len(candidates))
+		assigned := make([]int32, count)
+		copy(assigned, candidates[:count])
+		for _, authorId := range assigned {
+			load[authorId]++
+		}
+		ReviewAssignments[reviewerId] = assigned
+	}
+}
+
+// IsReviewAssigned reports whether reviewerId was assigned authorId's submission.
+func IsReviewAssigned(reviewerId int32, authorId int32) bool {
+	for _, id := range ReviewAssignments[reviewerId] {
+		if id == authorId {
+			return true
+		}
+	}
+	return false
+}
+
+// HasReviewed reports whether reviewerId has already reviewed authorId's submission.
+// Retracted reviews don't count, so the reviewer may write a new one.
+func HasReviewed(reviewerId int32, authorId int32) bool {
+	for _, review := range Submissions[authorId].CodeReviews {
+		if review.ReviewerId == reviewerId && !review.Retracted {
+			return true
+		}
+	}
+	return false
+}
+
+func FindUserIdByName(name string) (int32, bool) {
+	for id, user := range Users {
+		if user.Name == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
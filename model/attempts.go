@@ -0,0 +1,115 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// Attempt is an output checked against one of the current problem's test cases.
+type Attempt struct {
+	UserId   int32
+	TestCase int
+	Output   interface{} // as sent, kept so the attempt can be rejudged
+	Time     time.Time
+	Version  int // version of the problem the attempt was judged against
+	Correct  bool
+}
+
+var Attempts []Attempt // this round's attempts, oldest first
+
+// judgeAttempt checks attempt against problem. Attempts at test cases that no
+// longer exist, or whose output doesn't fit the test case, are wrong.
+func judgeAttempt(problem *Problem, attempt *Attempt) {
+	attempt.Version = problem.Version
+	attempt.Correct = false
+	if attempt.TestCase < 0 || attempt.TestCase >= len(problem.TestCases) {
+		return
+	}
+	correct, err := problem.TestCases[attempt.TestCase].CheckOutput(attempt.Output)
+	attempt.Correct = err == nil && correct
+}
+
+// RecordAttempt judges output against test case testCase of the current
+// problem and records the attempt. Returns an error if the output can't be
+// compared to the test case, then nothing is recorded.
+func RecordAttempt(userId int32, testCase int, output interface{}) (bool, error) {
+	problem := GetCurrentProblem()
+	correct, err := problem.TestCases[testCase].CheckOutput(output)
+	if err != nil {
+		return false, err
+	}
+	Attempts = append(Attempts, Attempt{
+		UserId:   userId,
+		TestCase: testCase,
+		Output:   output,
+		Time:     time.Now(),
+		Version:  problem.Version,
+		Correct:  correct,
+	})
+	return correct, nil
+}
+
+// SolveTime returns when userId had passed every test case of the current
+// problem, ok is false if they haven't yet.
+func SolveTime(userId int32) (solved time.Time, ok bool) {
+	problem := GetCurrentProblem()
+	passed := make(map[int]time.Time) // LOOKUP BY TEST CASE INDEX, FIRST CORRECT ATTEMPT
+	for _, attempt := range Attempts {
+		if attempt.UserId != userId || !attempt.Correct {
+			continue
+		}
+		if _, ok := passed[attempt.TestCase]; !ok {
+			passed[attempt.TestCase] = attempt.Time
+		}
+	}
+	if len(problem.TestCases) == 0 || len(passed) < len(problem.TestCases) {
+		return time.Time{}, false
+	}
+	for _, at := range passed {
+		if at.After(solved) {
+			solved = at
+		}
+	}
+	return solved, true
+}
+
+type SpeedEntry struct {
+	UserId   int32
+	Solved   time.Time
+	Elapsed  time.Duration // since the round started
+	Attempts int
+}
+
+// SpeedLeaderboard ranks the users who solved the current problem by how soon
+// they did, the fewest attempts first on ties.
+func SpeedLeaderboard() []SpeedEntry {
+	attempts := make(map[int32]int)
+	for _, attempt := range Attempts {
+		attempts[attempt.UserId]++
+	}
+
+	var entries []SpeedEntry
+	for userId, count := range attempts {
+		if !IsValidUserId(userId) {
+			continue
+		}
+		solved, ok := SolveTime(userId)
+		if !ok {
+			continue
+		}
+		entries = append(entries, SpeedEntry{
+			UserId:   userId,
+			Solved:   solved,
+			Elapsed:  solved.Sub(cycleState.roundStart),
+			Attempts: count,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Solved.Equal(entries[j].Solved) {
+			return entries[i].Solved.Before(entries[j].Solved)
+		}
+		return entries[i].Attempts < entries[j].Attempts
+	})
+	return entries
+}
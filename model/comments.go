@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var nextCommentId uint32 = 1
+
+// NewCommentId hands out the ID of an inline comment. Only call it once the
+// comment is stored, so rejected comments don't use up IDs.
+func NewCommentId() uint32 {
+	id := nextCommentId
+	nextCommentId++
+	return id
+}
+
+func countLines(code string) int {
+	return strings.Count(strings.TrimSuffix(code, "\n"), "\n") + 1
+}
+
+// NewInlineComment checks that the line range exists in the author's submitted
+// file and returns a comment without an ID yet.
+func NewInlineComment(authorId int32, file string, startLine int, endLine int, msg string) (InlineComment, error) {
+	var comment InlineComment
+
+	if msg == "" {
+		return comment, fmt.Errorf("comment message is empty")
+	}
+
+	var source *SourceFile
+	for i := range Submissions[authorId].Source {
+		if Submissions[authorId].Source[i].Name == file {
+			source = &Submissions[authorId].Source[i]
+			break
+		}
+	}
+	if source == nil {
+		return comment, fmt.Errorf("no file named '%s' in the submission", file)
+	}
+
+	lineCount := countLines(source.Code)
+	if startLine < 1 || endLine < startLine || endLine > lineCount {
+		return comment, fmt.Errorf("invalid line range %d-%d, '%s' has %d lines", startLine, endLine, file, lineCount)
+	}
+
+	comment.File = file
+	comment.StartLine = startLine
+	comment.EndLine = endLine
+	comment.Msg = msg
+	return comment, nil
+}
+
+// InlineCommentsOpen returns an error outside the review and results phases,
+// the phases inline comments may be added in, with a review or on their own.
+func InlineCommentsOpen() error {
+	if cycleState.Cycle != Review && cycleState.Cycle != Results {
+		return fmt.Errorf("inline comments are only allowed during the review and results phases")
+	}
+	return nil
+}
+
+// AddInlineComment attaches comment to reviewerId's existing review of
+// authorId's submission, during the review and results phases, and returns the
+// comment's ID.
+func AddInlineComment(authorId int32, reviewerId int32, comment InlineComment) (uint32, error) {
+	if err := InlineCommentsOpen(); err != nil {
+		return 0, err
+	}
+	sub, ok := Submissions[authorId]
+	if !ok {
+		return 0, fmt.Errorf("no submission to comment on")
+	}
+	for i := range sub.CodeReviews {
+		if sub.CodeReviews[i].ReviewerId == reviewerId && sub.CodeReviews[i].IsVisible() {
+			comment.Id = NewCommentId()
+			sub.CodeReviews[i].Comments = append(sub.CodeReviews[i].Comments, comment)
+			Submissions[authorId] = sub
+			return comment.Id, nil
+		}
+	}
+	return 0, fmt.Errorf("submit a review before adding inline comments")
+}
+
+// ReplyToComment adds a reply to a comment thread on authorId's submission.
+// Threads are open during the results phase, to the author and to the reviewer
+// who started the thread.
+func ReplyToComment(authorId int32, commentId uint32, replierId int32, msg string) error {
+	if cycleState.Cycle != Results {
+		return fmt.Errorf("replies are only allowed during the results phase")
+	}
+	if msg == "" {
+		return fmt.Errorf("reply message is empty")
+	}
+
+	sub, ok := Submissions[authorId]
+	if !ok {
+		return fmt.Errorf("no submission found")
+	}
+	for i := range sub.CodeReviews {
+		review := &sub.CodeReviews[i]
+		for j := range review.Comments {
+			comment := &review.Comments[j]
+			if comment.Id != commentId {
+				continue
+			}
+			if replierId != authorId && replierId != review.ReviewerId {
+				return fmt.Errorf("only the author and the reviewer may reply to this comment")
+			}
+			comment.Replies = append(comment.Replies, CommentReply{
+				AuthorId: replierId,
+				Msg:      msg,
+				Time:     time.Now(),
+			})
+			Submissions[authorId] = sub
+			return nil
+		}
+	}
+	return fmt.Errorf("no comment with id %d", commentId)
+}
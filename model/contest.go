@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ContestLocation returns the time zone the contest's times are given in.
+func ContestLocation() *time.Location {
+	if CurrentSchedule.Location != nil {
+		return CurrentSchedule.Location
+	}
+	return time.Local
+}
+
+// startContest opens the first round, lined up with the contest's start time.
+func startContest() {
+	cycleState.Cycle = Coding
+	cycleState.LastCycleTime = CurrentSchedule.Start
+	cycleState.roundStart = CurrentSchedule.Start
+}
+
+// contestOver reports whether the contest's end time has passed.
+func contestOver(now time.Time) bool {
+	return !CurrentSchedule.End.IsZero() && !now.Before(CurrentSchedule.End)
+}
+
+// roundFits reports whether a round with problem started at start would be
+// over by the contest's end time.
+func roundFits(problem *Problem, start time.Time) bool {
+	if CurrentSchedule.End.IsZero() {
+		return true
+	}
+	phases := roundPhases(problem, Coding, start)
+	return !phases[len(phases)-1].End.After(CurrentSchedule.End)
+}
+
+// nextRoundFits reports whether the round after the current one, started at
+// start, would be over by the contest's end time.
+func nextRoundFits(start time.Time) bool {
+	for _, name := range UpcomingProblems() {
+		if problem := FindProblem(name); problem != nil {
+			return roundFits(problem, start)
+		}
+	}
+	// the schedule loops or reshuffles, which starts from one of the problems
+	for i := range ProblemList {
+		if !roundFits(&ProblemList[i], start) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateContestTimes checks the start and end times of schedule.
+func validateContestTimes(schedule Schedule) error {
+	if !schedule.Start.IsZero() && !schedule.End.IsZero() && !schedule.Start.Before(schedule.End) {
+		return fmt.Errorf("the contest ends before it starts")
+	}
+	if !schedule.End.IsZero() && schedule.End.Before(time.Now()) {
+		return fmt.Errorf("the contest's end time has already passed")
+	}
+	return nil
+}
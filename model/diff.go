@@ -0,0 +1,234 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around every change.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// maxDiffEdits bounds the work of a diff: past this many inserted and deleted
+// lines the changed part is shown as removed and added whole. Memory grows
+// with its square, time with its product with the number of lines.
+const maxDiffEdits = 1000
+
+// diffLines returns a shortest edit script from a to b.
+func diffLines(a []string, b []string) []diffOp {
+	// lines shared at both ends are left out of the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// myersDiff is Myers' O(ND) diff: v[k] is the furthest x reached on diagonal
+// k = x - y with d edits, trace keeps v after every d to walk the path back.
+func myersDiff(a []string, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := min(n+m, maxDiffEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int // trace[d][k+d]
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down, an insertion
+			} else {
+				x = v[offset+k-1] + 1 // right, a deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+				return backtrackDiff(a, b, trace)
+			}
+		}
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+	}
+
+	var ops []diffOp
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	return ops
+}
+
+// backtrackDiff walks the path found by myersDiff from its end to its start.
+func backtrackDiff(a []string, b []string, trace [][]int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if prevK == k+1 {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{' ', a[x-1]})
+		x--
+		y--
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+// UnifiedDiff returns the changes from a to b in unified diff format, or an
+// empty string if they are equal.
+func UnifiedDiff(fromName string, toName string, a string, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// find the next change, then grow the hunk until diffContext*2
+		// unchanged lines separate it from the change after
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > diffContext*2 {
+				break
+			}
+			end = next
+		}
+		hunkStart := max(start-diffContext, 0)
+		hunkEnd := min(end+diffContext, len(ops))
+
+		// line numbers of the hunk's first line in a and b
+		fromLine, toLine := 1, 1
+		for _, op := range ops[:hunkStart] {
+			if op.kind != '+' {
+				fromLine++
+			}
+			if op.kind != '-' {
+				toLine++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				fromCount++
+			}
+			if op.kind != '-' {
+				toCount++
+			}
+		}
+		if fromCount == 0 {
+			fromLine--
+		}
+		if toCount == 0 {
+			toLine--
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+		for _, op := range ops[hunkStart:hunkEnd] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+// DiffSourceFiles returns a unified diff of every file that differs between
+// two versions of a submission. Added and removed files are diffed against /dev/null.
+func DiffSourceFiles(from []SourceFile, to []SourceFile) string {
+	fromFiles := make(map[string]string)
+	toFiles := make(map[string]string)
+	var names []string
+	for _, file := range from {
+		fromFiles[file.Name] = file.Code
+		names = append(names, file.Name)
+	}
+	for _, file := range to {
+		toFiles[file.Name] = file.Code
+		if _, ok := fromFiles[file.Name]; !ok {
+			names = append(names, file.Name)
+		}
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		fromName, toName := "a/"+name, "b/"+name
+		fromCode, inFrom := fromFiles[name]
+		toCode, inTo := toFiles[name]
+		if !inFrom {
+			fromName = "/dev/null"
+		}
+		if !inTo {
+			toName = "/dev/null"
+		}
+		out.WriteString(UnifiedDiff(fromName, toName, fromCode, toCode))
+	}
+	return out.String()
+}
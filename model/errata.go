@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Errata is a corrected version of the problem in play, published by an admin.
+type Errata struct {
+	Problem     string
+	FromVersion int
+	ToVersion   int
+	Time        time.Time
+	Note        string  // what was corrected, shown to contestants
+	Rejudged    int     // attempts judged again
+	Changed     int     // attempts whose verdict changed
+	Affected    []int32 // users who had an attempt change or who solved or unsolved the problem
+}
+
+var ErrataLog []Errata // oldest first
+
+// Notification tells a user that errata changed their verdicts.
+type Notification struct {
+	Id        uint32
+	Time      time.Time
+	Problem   string
+	Version   int
+	Note      string
+	Changed   int  // the user's attempts whose verdict changed
+	WasSolved bool // before the rejudge
+	Solved    bool // after the rejudge
+}
+
+var Notifications map[int32][]Notification // LOOKUP BY PRIVATE ID, OLDEST FIRST
+
+var nextNotificationId uint32 = 1
+
+func notify(userId int32, notification Notification) {
+	notification.Id = nextNotificationId
+	nextNotificationId++
+	notification.Time = time.Now()
+	Notifications[userId] = append(Notifications[userId], notification)
+}
+
+// ApplyErrata publishes the version of the problem in play that was reloaded
+// from disk. Every attempt is rejudged against it, and users whose verdicts
+// changed are notified with note.
+func ApplyErrata(note string) (*Errata, error) {
+	if ProblemsReload.Removed {
+		return nil, fmt.Errorf("the current problem was removed, it is kept until the next cycle")
+	}
+	if !ProblemsReload.ErrataPending {
+		return nil, fmt.Errorf("no errata pending")
+	}
+
+	old := GetCurrentProblem()
+	wasSolved := make(map[int32]bool)
+	for _, attempt := range Attempts {
+		_, wasSolved[attempt.UserId] = SolveTime(attempt.UserId)
+	}
+
+	errata := Errata{Problem: old.Header.Name, FromVersion: old.Version, Time: time.Now(), Note: note}
+	pinnedProblem = nil
+	ProblemsReload.ErrataPending = false
+	problem := GetCurrentProblem()
+	errata.ToVersion = problem.Version
+
+	changed := make(map[int32]int)
+	for i := range Attempts {
+		correct := Attempts[i].Correct
+		judgeAttempt(problem, &Attempts[i])
+		errata.Rejudged++
+		if Attempts[i].Correct != correct {
+			changed[Attempts[i].UserId]++
+			errata.Changed++
+		}
+	}
+
+	for userId, before := range wasSolved {
+		_, after := SolveTime(userId)
+		if changed[userId] == 0 && before == after {
+			continue
+		}
+		errata.Affected = append(errata.Affected, userId)
+		notify(userId, Notification{
+			Problem:   errata.Problem,
+			Version:   errata.ToVersion,
+			Note:      note,
+			Changed:   changed[userId],
+			WasSolved: before,
+			Solved:    after,
+		})
+	}
+
+	slices.Sort(errata.Affected)
+	ErrataLog = append(ErrataLog, errata)
+	return &ErrataLog[len(ErrataLog)-1], nil
+}
+
+// CurrentErrata returns the errata published this round, oldest first.
+func CurrentErrata() []Errata {
+	var errata []Errata
+	for _, e := range ErrataLog {
+		if !e.Time.Before(cycleState.roundStart) {
+			errata = append(errata, e)
+		}
+	}
+	return errata
+}
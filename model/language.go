@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// ValidateLanguageTag checks that tag looks like a language tag, such as "en" or "pt-BR".
+func ValidateLanguageTag(tag string) error {
+	if !languageTagPattern.MatchString(tag) {
+		return fmt.Errorf("invalid language '%s', expects a tag like \"en\" or \"pt-BR\"", tag)
+	}
+	return nil
+}
+
+// LocalizedStatement returns the problem's statement in language, which must
+// be one of its Languages.
+func (p *Problem) LocalizedStatement(language string) Translation {
+	if translation, ok := p.Translations[language]; ok {
+		return translation
+	}
+	return Translation{p.Statement, p.StatementHTML}
+}
+
+// SetUserLanguage sets the language a user prefers problem statements in.
+func SetUserLanguage(userId int32, language string) error {
+	user, ok := Users[userId]
+	if !ok {
+		return fmt.Errorf("unknown user")
+	}
+	if language != "" {
+		if err := ValidateLanguageTag(language); err != nil {
+			return err
+		}
+	}
+	user.Language = language
+	Users[userId] = user
+	return nil
+}
@@ -0,0 +1,54 @@
+package model
+
+import "sort"
+
+type QualityEntry struct {
+	AuthorId      int32
+	AverageStars  float64 // raw average of the reviews' stars
+	AdjustedStars float64 // calibrated per reviewer and weighted by reviewer reputation
+	ReviewCount   int
+	Criteria      map[string]float64
+}
+
+// QualityLeaderboard ranks the current round's submissions by their calibrated,
+// reputation-weighted stars. Submissions without reviews are ranked last.
+func QualityLeaderboard() []QualityEntry {
+	stats, globalMean, globalStdDev := ReviewerReputation()
+
+	var entries []QualityEntry
+	for authorId, sub := range Submissions {
+		if !IsValidUserId(authorId) {
+			continue
+		}
+		reviews := VisibleReviews(sub.CodeReviews)
+		var entry QualityEntry
+		entry.AuthorId = authorId
+		entry.AverageStars = AverageStars(reviews)
+		entry.AdjustedStars = WeightedAdjustedStars(reviews, stats, globalMean, globalStdDev)
+		entry.ReviewCount = len(reviews)
+		entry.Criteria = CriterionAverages(reviews)
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].AdjustedStars != entries[j].AdjustedStars {
+			return entries[i].AdjustedStars > entries[j].AdjustedStars
+		}
+		return entries[i].ReviewCount > entries[j].ReviewCount
+	})
+	return entries
+}
+
+// CumulativeLeaderboard returns the IDs of every user with a score, most points first.
+func CumulativeLeaderboard() []int32 {
+	ids := make([]int32, 0, len(CumulativeScores))
+	for userId := range CumulativeScores {
+		if IsValidUserId(userId) {
+			ids = append(ids, userId)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return CumulativeScores[ids[i]].Points > CumulativeScores[ids[j]].Points
+	})
+	return ids
+}
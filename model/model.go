@@ -3,6 +3,7 @@ package model
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"sync"
 	"time"
@@ -21,12 +22,49 @@ type ProblemHeader struct {
 	Description string
 }
 
+type RubricCriterion struct {
+	Name   string
+	Scale  uint8   // scores range from 1 to Scale
+	Weight float64 // relative weight when combining scores into stars
+}
+
+// Translation is a problem statement in another language.
+type Translation struct {
+	Statement     string
+	StatementHTML string
+}
+
+// StarterTemplate is code contestants can start from, with the problem's I/O already handled.
+type StarterTemplate struct {
+	Language string // a key of Toolchains
+	FileName string
+	Code     string
+}
+
 type Problem struct {
 	Header     ProblemHeader
 	Difficulty ProblemDifficulty
 	Id         uint16
+	Revision   string // hash of the files the problem was loaded from
+	Version    int    // starts at 1, goes up every time a reload changes the problem
 	Objective  string
+	Source     string // where the problem comes from, like a contest, optional
 	TestCases  []TestCase
+	Rubric     []RubricCriterion
+	Durations  PhaseDurations // overrides the defaults of its difficulty, zero fields keep them
+
+	Statement     string                 // Markdown, the Objective when the problem has no statement
+	StatementHTML string                 // Statement rendered to sanitized HTML
+	Images        map[string][]byte      `json:"-"` // LOOKUP BY IMAGE SOURCE IN THE STATEMENT
+	Language      string                 // language of Statement, like "en"
+	Languages     []string               // every language the statement is available in, Language first
+	Translations  map[string]Translation `json:"-"` // LOOKUP BY LANGUAGE, Language excluded
+
+	AllowedExtensions []string       // overrides the submission policy's extensions when not empty
+	Manifest          *ManifestRules // nil for DefaultManifestRules
+	Starters          []StarterTemplate
+	Checker           []SourceFile `json:"-"` // custom output checker of a problem pack, for judges
+	Solution          []SourceFile `json:"-"` // reference solution of a problem pack, never sent to contestants
 }
 
 type SourceFile struct {
@@ -34,24 +72,82 @@ type SourceFile struct {
 	Code string
 }
 type TestCase struct {
-	Input         string
-	OutputJSON    map[string]interface{}
+	Mode          TestCaseMode
+	Input         string                 // JSON encoded in JSONMode, raw stdin in TextMode
+	OutputJSON    map[string]interface{} // expected output in JSONMode
+	OutputText    string                 // expected output in TextMode
+	Comparison    TextComparison         // how OutputText is compared, TextMode only
 	CaseSensitive bool
+
+	Name       string        // optional, like "secret/group1/big", keeps the test groups of a Kattis package
+	External   bool          // the data is in files, too large to send inline
+	Sample     bool          // contestants may download the data files
+	InputFile  *TestDataFile `json:"-"` // replaces Input when not nil
+	OutputFile *TestDataFile `json:"-"` // replaces the expected output when not nil
+	dataLoaded bool
 }
 
 type User struct {
-	Name string
-	Id   int32
+	Name     string
+	Id       int32
+	Team     string // optional; users on the same team never review each other
+	IsAdmin  bool
+	Language string // preferred language of problem statements, empty for none
+}
+
+type CommentReply struct {
+	AuthorId int32
+	Msg      string
+	Time     time.Time
+}
+
+// InlineComment is attached to a line range of one of the reviewed source files.
+// Lines are 1-based and inclusive.
+type InlineComment struct {
+	Id        uint32
+	File      string
+	StartLine int
+	EndLine   int
+	Msg       string
+	Replies   []CommentReply
+}
+
+// ReviewRevision is an earlier version of an edited code review, kept for audit.
+type ReviewRevision struct {
+	Stars  uint8
+	Scores map[string]uint8
+	Msg    string
+	Time   time.Time
 }
 
 type CodeReview struct {
+	Id         uint32
 	Stars      uint8
+	Scores     map[string]uint8 // LOOKUP BY RUBRIC CRITERION NAME
 	Msg        string
 	ReviewerId int32
+	Comments   []InlineComment
+	History    []ReviewRevision // previous versions, oldest first
+	Retracted  bool             // deleted by the reviewer
+	Flagged    bool             // reported by the author, waiting for moderation
+	FlagReason string
+	Moderated  bool  // hidden by an admin
+	Helpful    uint8 // 1 to 5 as rated by the submission's author, 0 if not rated
+}
+type SubmissionVersion struct {
+	Number   int // starting at 1
+	Time     time.Time
+	Hash     string // hex SHA-256 of the file names and contents
+	Source   []SourceFile
+	Manifest *BuildManifest // nil if the version has no manifest
 }
+
 type Submission struct {
-	Source      []SourceFile
-	CodeReviews []CodeReview
+	Source          []SourceFile   // the version that counts for grading
+	Manifest        *BuildManifest // manifest of the version that counts for grading
+	CodeReviews     []CodeReview
+	Versions        []SubmissionVersion // every version submitted this round, oldest first
+	SelectedVersion int                 // version chosen by an admin, 0 to count the latest
 }
 
 type CycleTime int
@@ -59,6 +155,9 @@ type CycleTime int
 const (
 	Coding = iota
 	Review
+	Results
+	Finished // the schedule is over, the last results stay up
+	Lobby    // waiting for the contest's start time
 )
 
 type CycleState struct {
@@ -67,47 +166,140 @@ type CycleState struct {
 	Cycle             CycleTime
 	codingDurMins     float64 // time of the coding cycle, in minutes
 	reviewDurMins     float64 // time of the review cycle in minutes
+	resultsDurMins    float64 // time of the results cycle in minutes
 	activeUserCount   uint32
 	submittedCount    uint32
+	round             uint32 // number of rounds played so far
+	roundStart        time.Time
+	finished          bool // the schedule was exhausted and stopped
+}
+
+type BlindMode int
+
+const (
+	NotBlind    = iota
+	SingleBlind // reviewers are hidden from authors
+	DoubleBlind // reviewers and authors are hidden from each other
+)
+
+type RoundSettings struct {
+	ReviewsPerUser       int  // number of submissions each submitter is assigned to review
+	RestrictToAssignment bool // reject reviews of submissions outside the reviewer's assignment
+	Blind                BlindMode
+	Rubric               []RubricCriterion // overrides the problem's rubric when not empty
+	MinReviews           int               // reviews each submitter has to write this round
+	MinReviewLength      int               // characters a review message needs to count towards MinReviews
+	QuotaPenalty         float64           // points lost per missing review
+	HelpfulBonus         float64           // points won per review its recipient rated as helpful
+	SimilarityThreshold  float64           // similarity from which a pair of submissions is flagged, 0 to 1
+	Submission           SubmissionPolicy
 }
 
 var Users map[int32]User // LOOKUP BY PRIVATE ID
 
 var Submissions map[int32]Submission // LOOKUP BY PRIVATE ID
 
+var ReviewAssignments map[int32][]int32 // LOOKUP BY REVIEWER PRIVATE ID, VALUES ARE AUTHOR PRIVATE IDS
+
+var ReviewHistory []ArchivedReview // reviews of all finished rounds
+
+var CumulativeScores map[int32]CumulativeScore // LOOKUP BY PRIVATE ID
+
 var ProblemList []Problem
 
+var Settings RoundSettings
+
 var cycleState CycleState
 
 var Mutex sync.Mutex
 
+var AdminKey string // users that join any room with this key are admins of it
+
 func Init() {
+	Rooms = make(map[string]*Room)
+
+	var key [16]byte
+	rand.Read(key[:])
+	AdminKey = hex.EncodeToString(key[:])
+}
+
+// resetRoom gives the selected room no users, no problems and the default
+// round settings.
+func resetRoom() {
 	Users = make(map[int32]User)
 	Submissions = make(map[int32]Submission)
+	ReviewAssignments = make(map[int32][]int32)
+	CumulativeScores = make(map[int32]CumulativeScore)
+	Notifications = make(map[int32][]Notification)
+	Settings.ReviewsPerUser = 3
+	Settings.RestrictToAssignment = true
+	Settings.MinReviews = 2
+	Settings.MinReviewLength = 40
+	Settings.QuotaPenalty = 5
+	Settings.HelpfulBonus = 2
+	Settings.SimilarityThreshold = 0.5
+	Settings.Submission.MaxFiles = 20
+	Settings.Submission.MaxFileBytes = 256 * 1024
+	Settings.Submission.MaxTotalBytes = 1024 * 1024
+	Settings.Submission.MaxVersions = 50
 	cycleState.currentProblemIdx = 0
 	cycleState.LastCycleTime = time.Now()
+	cycleState.roundStart = cycleState.LastCycleTime
 	cycleState.codingDurMins = 30.0
 	cycleState.reviewDurMins = 10.0
+	cycleState.resultsDurMins = 5.0
+	resetPseudonyms()
 }
 
 func Tick() {
+	now := time.Now()
+	if cycleState.Cycle == Lobby {
+		if !now.Before(CurrentSchedule.Start) {
+			startContest()
+		}
+		return
+	}
+	if cycleState.Cycle != Finished && contestOver(now) {
+		// FREEZE THE FINAL RESULTS
+		finishSchedule()
+		return
+	}
 
-	elapsed := time.Since(cycleState.LastCycleTime)
-	minutes := elapsed.Minutes()
+	elapsed := now.Sub(cycleState.LastCycleTime)
+	codingDur, reviewDur := ProblemPhaseDurations(GetCurrentProblem())
+	resultsDur := minutesDuration(cycleState.resultsDurMins)
 
-	if cycleState.Cycle == Coding && minutes > cycleState.codingDurMins {
-		cycleState.LastCycleTime = time.Now()
+	// phases end on time rather than on the tick that noticed it
+	if cycleState.Cycle == Coding && elapsed > codingDur {
+		cycleState.LastCycleTime = cycleState.LastCycleTime.Add(codingDur)
 		cycleState.Cycle = Review
-	} else if cycleState.Cycle == Review && minutes > cycleState.reviewDurMins {
+		RunSimilarityCheck()
+		AssignReviews()
+	} else if cycleState.Cycle == Review && elapsed > reviewDur {
+		cycleState.LastCycleTime = cycleState.LastCycleTime.Add(reviewDur)
+		cycleState.Cycle = Results
+	} else if cycleState.Cycle == Results && elapsed > resultsDur {
+		next := cycleState.LastCycleTime.Add(resultsDur)
+		if scheduleExhausted() || !nextRoundFits(next) {
+			finishSchedule()
+			return
+		}
 		// PROCEED TO NEXT PROBLEM
-		cycleState.Cycle = Coding
 		CycleProblem()
+		cycleState.LastCycleTime = next
+		cycleState.roundStart = next
 	}
 }
 
 func GetCycleTimeLeftSeconds() float64 {
-	elapsed := time.Since(cycleState.LastCycleTime)
-	return elapsed.Seconds()
+	switch cycleState.Cycle {
+	case Finished:
+		return 0
+	case Lobby:
+		return max(time.Until(CurrentSchedule.Start).Seconds(), 0)
+	}
+	end := cycleState.LastCycleTime.Add(currentPhaseDuration())
+	return max(time.Until(end).Seconds(), 0)
 }
 
 func GetCycleState() CycleTime {
@@ -115,15 +307,30 @@ func GetCycleState() CycleTime {
 }
 
 func CycleProblem() {
+	scoreRound()
+	archiveReviews()
+	cycleState.round++
 	Submissions = make(map[int32]Submission)
+	ReviewAssignments = make(map[int32][]int32)
+	SimilarityReport = nil
+	Attempts = nil
+	resetPseudonyms()
+	clearPinnedProblem()
 	cycleState.LastCycleTime = time.Now()
-	cycleState.currentProblemIdx++
-	if cycleState.currentProblemIdx >= uint32(len(ProblemList)) {
-		cycleState.currentProblemIdx = 0
+	cycleState.roundStart = cycleState.LastCycleTime
+	if !nextScheduledProblem() {
+		cycleState.Cycle = Finished
+		cycleState.finished = true
+		return
 	}
+	// set after scoreRound, which looks at the phase the round ended in
+	cycleState.Cycle = Coding
 }
 
 func GetCurrentProblem() *Problem {
+	if pinnedProblem != nil {
+		return pinnedProblem
+	}
 	return &ProblemList[cycleState.currentProblemIdx]
 }
 
@@ -163,15 +370,36 @@ func IsAuthedRequest(received map[string]interface{}) (bool, int32) {
 	}
 }
 
+func IsAdmin(userId int32) bool {
+	return Users[userId].IsAdmin
+}
+
 func IsValidUserId(userId int32) bool {
 	_, ok := Users[userId]
 	return ok
 }
 
-func AddSubmission(uId int32, sourceFiles []SourceFile) {
+// AddSubmission keeps sourceFiles as uId's next version, unless uId already
+// submitted as many versions as the submission policy allows this round.
+func AddSubmission(uId int32, sourceFiles []SourceFile, manifest *BuildManifest) error {
 	sub := Submissions[uId]
-	sub.Source = sourceFiles
+	maxVersions := Settings.Submission.MaxVersions
+	if maxVersions > 0 && len(sub.Versions) >= maxVersions {
+		return fmt.Errorf("at most %d versions may be submitted per round", maxVersions)
+	}
+	sub.Versions = append(sub.Versions, SubmissionVersion{
+		Number:   len(sub.Versions) + 1,
+		Time:     time.Now(),
+		Hash:     hashSourceFiles(sourceFiles),
+		Source:   sourceFiles,
+		Manifest: manifest,
+	})
+	if sub.SelectedVersion == 0 {
+		sub.Source = sourceFiles
+		sub.Manifest = manifest
+	}
 	Submissions[uId] = sub
+	return nil
 }
 
 func AddCodeReview(codeOwnerName string, reviewerId int32, stars uint8, msg string) bool {
@@ -216,10 +444,12 @@ func AddCodeReview(codeOwnerName string, reviewerId int32, stars uint8, msg stri
 	return true
 }
 
-func AddUser(name string) (error, int32) {
+func AddUser(name string, team string, isAdmin bool) (error, int32) {
 	var u User
 	var err error
 	u.Name = name
+	u.Team = team
+	u.IsAdmin = isAdmin
 	u.Id, err = generateSecureRandomInt32()
 	if err != nil {
 		return err, 0
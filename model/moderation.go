@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+var nextReviewId uint32 = 1
+
+func NewReviewId() uint32 {
+	id := nextReviewId
+	nextReviewId++
+	return id
+}
+
+// IsVisible reports whether the review is shown and counts towards star averages.
+func (review CodeReview) IsVisible() bool {
+	return !review.Retracted && !review.Moderated
+}
+
+// VisibleReviews returns the reviews that were neither retracted nor moderated.
+func VisibleReviews(reviews []CodeReview) []CodeReview {
+	var visible []CodeReview
+	for _, review := range reviews {
+		if review.IsVisible() {
+			visible = append(visible, review)
+		}
+	}
+	return visible
+}
+
+// FindCodeReview returns the author of the submission a review belongs to, and
+// a pointer to the review inside Submissions.
+func FindCodeReview(reviewId uint32) (int32, *CodeReview, bool) {
+	for authorId, sub := range Submissions {
+		for i := range sub.CodeReviews {
+			if sub.CodeReviews[i].Id == reviewId {
+				return authorId, &sub.CodeReviews[i], true
+			}
+		}
+	}
+	return 0, nil, false
+}
+
+// findOwnReview looks up a review that reviewerId may still change.
+func findOwnReview(reviewId uint32, reviewerId int32) (*CodeReview, error) {
+	if cycleState.Cycle != Review {
+		return nil, fmt.Errorf("reviews can only be changed during the review phase")
+	}
+	_, review, ok := FindCodeReview(reviewId)
+	if !ok || review.Retracted || review.ReviewerId != reviewerId {
+		return nil, fmt.Errorf("no review of yours with id %d", reviewId)
+	}
+	if review.Moderated {
+		return nil, fmt.Errorf("review %d was removed by a moderator", reviewId)
+	}
+	return review, nil
+}
+
+// EditCodeReview replaces the rating and message of a review, keeping the
+// previous version in its history.
+func EditCodeReview(reviewId uint32, reviewerId int32, stars uint8, scores map[string]uint8, msg string) error {
+	review, err := findOwnReview(reviewId, reviewerId)
+	if err != nil {
+		return err
+	}
+	review.History = append(review.History, ReviewRevision{
+		Stars:  review.Stars,
+		Scores: review.Scores,
+		Msg:    review.Msg,
+		Time:   time.Now(),
+	})
+	review.Stars = stars
+	review.Scores = scores
+	review.Msg = msg
+	return nil
+}
+
+// RetractCodeReview hides a review on behalf of its reviewer. It is kept for audit.
+func RetractCodeReview(reviewId uint32, reviewerId int32) error {
+	review, err := findOwnReview(reviewId, reviewerId)
+	if err != nil {
+		return err
+	}
+	review.Retracted = true
+	return nil
+}
+
+// FlagCodeReview reports a review on userId's own submission to the admins.
+func FlagCodeReview(reviewId uint32, userId int32, reason string) error {
+	authorId, review, ok := FindCodeReview(reviewId)
+	if !ok || authorId != userId || !review.IsVisible() {
+		return fmt.Errorf("no review of your submission with id %d", reviewId)
+	}
+	review.Flagged = true
+	review.FlagReason = reason
+	return nil
+}
+
+type ModerationItem struct {
+	AuthorId int32
+	Review   CodeReview
+}
+
+// ModerationQueue returns the flagged reviews that are waiting for an admin.
+func ModerationQueue() []ModerationItem {
+	var queue []ModerationItem
+	for authorId, sub := range Submissions {
+		for _, review := range sub.CodeReviews {
+			if review.Flagged && review.IsVisible() {
+				queue = append(queue, ModerationItem{AuthorId: authorId, Review: review})
+			}
+		}
+	}
+	return queue
+}
+
+// ModerateCodeReview resolves a flag: hide removes the review from view and from
+// the star averages, otherwise the flag is dismissed.
+func ModerateCodeReview(reviewId uint32, hide bool) error {
+	_, review, ok := FindCodeReview(reviewId)
+	if !ok {
+		return fmt.Errorf("no review with id %d", reviewId)
+	}
+	review.Flagged = false
+	review.Moderated = hide
+	return nil
+}
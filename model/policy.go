@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+type SubmissionPolicy struct {
+	MaxFiles          int
+	MaxFileBytes      int
+	MaxTotalBytes     int
+	MaxVersions       int      // versions kept per user and round
+	AllowedExtensions []string // like ".c", any extension is allowed when empty
+}
+
+// maxFileNameLength is the longest file name, including directories, a submission may use.
+const maxFileNameLength = 255
+
+// binarySniffBytes is how much of a file is inspected to decide whether it is binary.
+const binarySniffBytes = 8000
+
+// AllowedExtensions returns the file extensions accepted for the current problem.
+func AllowedExtensions() []string {
+	if len(ProblemList) > 0 && len(GetCurrentProblem().AllowedExtensions) > 0 {
+		return GetCurrentProblem().AllowedExtensions
+	}
+	return Settings.Submission.AllowedExtensions
+}
+
+// ValidateFileName checks that name is a relative path that stays inside the
+// submission once written to disk, like "main.c" or "src/util.c".
+func ValidateFileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("file name is empty")
+	}
+	if len(name) > maxFileNameLength {
+		return fmt.Errorf("file name '%.32s...' is longer than %d characters", name, maxFileNameLength)
+	}
+	for _, c := range name {
+		isAllowed := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+			c == '.' || c == '_' || c == '-' || c == '+' || c == '/'
+		if !isAllowed {
+			return fmt.Errorf("file name '%s' contains '%c', only letters, digits and . _ - + / are allowed", name, c)
+		}
+	}
+	if strings.HasPrefix(name, "/") {
+		return fmt.Errorf("file name '%s' must be a relative path", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("file name '%s' must not contain empty, '.' or '..' path elements", name)
+		}
+	}
+	if path.Clean(name) != name {
+		return fmt.Errorf("file name '%s' is not a clean path", name)
+	}
+	return nil
+}
+
+// isBinary reports whether code looks like a binary file rather than text:
+// it contains a NUL byte or mostly control characters.
+func isBinary(code string) bool {
+	sample := code[:min(len(code), binarySniffBytes)]
+	if strings.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	control := 0
+	for i := 0; i < len(sample); i++ {
+		c := sample[i]
+		if c < 0x20 && c != '\n' && c != '\r' && c != '\t' && c != '\f' && c != '\v' {
+			control++
+		}
+	}
+	return control*10 > len(sample)
+}
+
+// ValidateSubmission checks source files against the submission policy and
+// returns an error describing the first problem found.
+func ValidateSubmission(sourceFiles []SourceFile) error {
+	policy := Settings.Submission
+	extensions := AllowedExtensions()
+
+	if len(sourceFiles) == 0 {
+		return fmt.Errorf("submission contains no files")
+	}
+	if policy.MaxFiles > 0 && len(sourceFiles) > policy.MaxFiles {
+		return fmt.Errorf("submission contains %d files, at most %d are allowed", len(sourceFiles), policy.MaxFiles)
+	}
+
+	names := make(map[string]bool)
+	total := 0
+	for _, file := range sourceFiles {
+		if err := ValidateFileName(file.Name); err != nil {
+			return err
+		}
+		if names[file.Name] {
+			return fmt.Errorf("file '%s' is submitted twice", file.Name)
+		}
+		names[file.Name] = true
+
+		if len(extensions) > 0 && file.Name != ManifestFileName {
+			ext := strings.ToLower(path.Ext(file.Name))
+			allowed := false
+			for _, allowedExt := range extensions {
+				if ext == strings.ToLower(allowedExt) {
+					allowed = true
+				}
+			}
+			if !allowed {
+				return fmt.Errorf("file '%s' has an extension that is not allowed, expected one of: %s", file.Name, strings.Join(extensions, ", "))
+			}
+		}
+
+		if policy.MaxFileBytes > 0 && len(file.Code) > policy.MaxFileBytes {
+			return fmt.Errorf("file '%s' is %d bytes, at most %d are allowed per file", file.Name, len(file.Code), policy.MaxFileBytes)
+		}
+		total += len(file.Code)
+
+		if !utf8.ValidString(file.Code) {
+			return fmt.Errorf("file '%s' is not valid UTF-8 text", file.Name)
+		}
+		if isBinary(file.Code) {
+			return fmt.Errorf("file '%s' looks like a binary file, only source code may be submitted", file.Name)
+		}
+	}
+
+	if policy.MaxTotalBytes > 0 && total > policy.MaxTotalBytes {
+		return fmt.Errorf("submission is %d bytes, at most %d are allowed in total", total, policy.MaxTotalBytes)
+	}
+	return nil
+}
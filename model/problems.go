@@ -0,0 +1,105 @@
+package model
+
+import "time"
+
+type ProblemReload struct {
+	Time          time.Time // last successful reload
+	Error         string    // error of the last failed reload, cleared by the next successful one
+	ErrataPending bool      // the problem in play changed on disk and is still pinned to its old version
+	Removed       bool      // the problem in play was removed from disk
+}
+
+var ProblemsReload ProblemReload
+
+// the problem in play, kept as it was when a reload changed or removed it
+var pinnedProblem *Problem
+
+// ReplaceProblems swaps in a freshly loaded problem list. The problem in play
+// keeps its old version until the next cycle, or until ApplyErrata is called.
+// The caller must hold Mutex.
+func ReplaceProblems(problems []Problem) {
+	ProblemsReload.Time = time.Now()
+	ProblemsReload.Error = ""
+	if len(ProblemList) == 0 && pinnedProblem == nil {
+		ProblemList = problems
+		cycleState.currentProblemIdx = 0
+		refreshSchedule()
+		return
+	}
+
+	current := *GetCurrentProblem()
+	// the old files may change again while it stays pinned
+	loadProblemData(&current)
+	numberVersions(problems, current)
+	ProblemList = problems
+	refreshSchedule()
+
+	for i := range ProblemList {
+		if ProblemList[i].Header.Name != current.Header.Name {
+			continue
+		}
+		cycleState.currentProblemIdx = uint32(i)
+		loadProblemData(&ProblemList[i])
+		ProblemsReload.Removed = false
+		ProblemsReload.ErrataPending = ProblemList[i].Revision != current.Revision
+		if ProblemsReload.ErrataPending {
+			pinnedProblem = &current
+		} else {
+			pinnedProblem = nil
+		}
+		return
+	}
+
+	// the schedule carries on without it, the index only has to stay valid
+	cycleState.currentProblemIdx = min(cycleState.currentProblemIdx, uint32(len(ProblemList)-1))
+	pinnedProblem = &current
+	ProblemsReload.Removed = true
+	ProblemsReload.ErrataPending = false
+}
+
+// numberVersions gives every reloaded problem the version of the problem it
+// replaces, or the next version if it changed. New problems start at version 1.
+func numberVersions(problems []Problem, current Problem) {
+	previous := make(map[string]*Problem) // LOOKUP BY PROBLEM NAME
+	for i := range ProblemList {
+		previous[ProblemList[i].Header.Name] = &ProblemList[i]
+	}
+	// a pinned problem may have been removed from the list, or be older than it
+	if old, ok := previous[current.Header.Name]; !ok || old.Version < current.Version {
+		previous[current.Header.Name] = &current
+	}
+
+	for i := range problems {
+		old, ok := previous[problems[i].Header.Name]
+		switch {
+		case !ok:
+			problems[i].Version = 1
+		case old.Revision == problems[i].Revision:
+			problems[i].Version = old.Version
+		default:
+			problems[i].Version = old.Version + 1
+		}
+	}
+}
+
+// loadProblemData reads the data files of every test case of problem, so the
+// problem in play doesn't depend on files that may be edited under it. Files
+// that fail to load fail again when their test case is checked.
+func loadProblemData(problem *Problem) {
+	for i := range problem.TestCases {
+		problem.TestCases[i].LoadData()
+	}
+}
+
+// ProblemsReloadFailed records a reload that was rejected. The problem list
+// is left as it was.
+func ProblemsReloadFailed(err error) {
+	ProblemsReload.Error = err.Error()
+}
+
+// clearPinnedProblem moves on from a pinned problem once the next one starts
+func clearPinnedProblem() {
+	pinnedProblem = nil
+	ProblemsReload.ErrataPending = false
+	ProblemsReload.Removed = false
+}
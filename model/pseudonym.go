@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var pseudonymAdjectives = []string{
+	"Amber", "Brave", "Calm", "Clever", "Swift", "Gentle", "Lucky", "Quiet",
+	"Bold", "Bright", "Curious", "Eager", "Jolly", "Mighty", "Nimble", "Witty",
+}
+
+var pseudonymAnimals = []string{
+	"Otter", "Falcon", "Badger", "Heron", "Lynx", "Panda", "Koala", "Marten",
+	"Gecko", "Puffin", "Bison", "Ibex", "Walrus", "Ferret", "Tapir", "Wombat",
+}
+
+var pseudonyms map[int32]string // LOOKUP BY PRIVATE ID
+
+var pseudonymPool []string
+
+// resetPseudonyms forgets every pseudonym handed out so far and reshuffles the
+// pool, so names are stable within a round but can't be linked across rounds.
+func resetPseudonyms() {
+	pseudonyms = make(map[int32]string)
+	pseudonymPool = pseudonymPool[:0]
+	for _, adjective := range pseudonymAdjectives {
+		for _, animal := range pseudonymAnimals {
+			pseudonymPool = append(pseudonymPool, adjective+" "+animal)
+		}
+	}
+	rand.Shuffle(len(pseudonymPool), func(i, j int) {
+		pseudonymPool[i], pseudonymPool[j] = pseudonymPool[j], pseudonymPool[i]
+	})
+}
+
+// Pseudonym returns the name the user is shown under for the current round.
+func Pseudonym(userId int32) string {
+	name, ok := pseudonyms[userId]
+	if ok {
+		return name
+	}
+	if len(pseudonymPool) > 0 {
+		name = pseudonymPool[len(pseudonymPool)-1]
+		pseudonymPool = pseudonymPool[:len(pseudonymPool)-1]
+	} else {
+		name = fmt.Sprintf("Participant %d", len(pseudonyms)+1)
+	}
+	pseudonyms[userId] = name
+	return name
+}
+
+// identitiesRevealed reports whether viewerId may see real names regardless of
+// the blind mode: always in the results phase and once the contest is over,
+// and always for admins.
+func identitiesRevealed(viewerId int32) bool {
+	return cycleState.Cycle == Results || cycleState.Cycle == Finished || IsAdmin(viewerId)
+}
+
+// AuthorDisplayName returns the name viewerId sees for the author of a submission.
+func AuthorDisplayName(viewerId int32, authorId int32) string {
+	if Settings.Blind == DoubleBlind && viewerId != authorId && !identitiesRevealed(viewerId) {
+		return Pseudonym(authorId)
+	}
+	return Users[authorId].Name
+}
+
+// ReviewerDisplayName returns the name viewerId sees for the author of a code review.
+func ReviewerDisplayName(viewerId int32, reviewerId int32) string {
+	if Settings.Blind != NotBlind && viewerId != reviewerId && !identitiesRevealed(viewerId) {
+		return Pseudonym(reviewerId)
+	}
+	return Users[reviewerId].Name
+}
+
+// FindAuthorByDisplayName resolves a name as shown to viewerId by
+// AuthorDisplayName back to the author's private ID. While authors are hidden
+// only their pseudonyms and the viewer's own name are accepted, so real names
+// can't be guessed.
+func FindAuthorByDisplayName(viewerId int32, name string) (int32, bool) {
+	if Settings.Blind == DoubleBlind && !identitiesRevealed(viewerId) {
+		if Users[viewerId].Name == name {
+			return viewerId, true
+		}
+		for id, pseudonym := range pseudonyms {
+			if pseudonym == name {
+				return id, true
+			}
+		}
+		return 0, false
+	}
+	return FindUserIdByName(name)
+}
+
+// IsPseudonymName reports whether name could be handed out as a pseudonym, such
+// names are kept from users so no one can pass for someone else's pseudonym.
+func IsPseudonymName(name string) bool {
+	adjective, animal, found := strings.Cut(name, " ")
+	if found && slices.ContainsFunc(pseudonymAdjectives, func(word string) bool { return strings.EqualFold(word, adjective) }) &&
+		slices.ContainsFunc(pseudonymAnimals, func(word string) bool { return strings.EqualFold(word, animal) }) {
+		return true
+	}
+	// the names handed out once the pool runs out
+	if number, ok := strings.CutPrefix(strings.ToLower(name), "participant "); ok {
+		_, err := strconv.Atoi(number)
+		return err == nil
+	}
+	return false
+}
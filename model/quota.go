@@ -0,0 +1,116 @@
+package model
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// qualityPointsPerStar converts a submission's adjusted stars into leaderboard points.
+const qualityPointsPerStar = 10.0
+
+// helpfulThreshold is the lowest helpfulness rating that earns the reviewer a bonus.
+const helpfulThreshold = 4
+
+type CumulativeScore struct {
+	Points        float64 // total of the fields below
+	QualityPoints float64
+	ReviewPenalty float64
+	ReviewBonus   float64
+	Rounds        int
+}
+
+// CountsTowardsQuota reports whether a review is long enough, and still shown,
+// to count towards its reviewer's quota.
+func CountsTowardsQuota(review CodeReview) bool {
+	return review.IsVisible() && utf8.RuneCountInString(review.Msg) >= Settings.MinReviewLength
+}
+
+// CompletedReviewCount returns how many of reviewerId's reviews this round count towards the quota.
+func CompletedReviewCount(reviewerId int32) int {
+	count := 0
+	for _, sub := range Submissions {
+		for _, review := range sub.CodeReviews {
+			if review.ReviewerId == reviewerId && CountsTowardsQuota(review) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// RequiredReviewCount returns the quota for userId this round. Only users who
+// submitted have one, and nobody has to review more than they were assigned.
+func RequiredReviewCount(userId int32) int {
+	if _, ok := Submissions[userId]; !ok {
+		return 0
+	}
+	required := Settings.MinReviews
+	if Settings.RestrictToAssignment && cycleState.Cycle != Coding {
+		required = min(required, len(ReviewAssignments[userId]))
+	}
+	return required
+}
+
+// RateCodeReview lets the author of a submission rate how helpful a review was.
+func RateCodeReview(reviewId uint32, userId int32, rating uint8) error {
+	authorId, review, ok := FindCodeReview(reviewId)
+	if !ok || authorId != userId || !review.IsVisible() {
+		return fmt.Errorf("no review of your submission with id %d", reviewId)
+	}
+	if rating < 1 || rating > 5 {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
+	review.Helpful = rating
+	return nil
+}
+
+// scoreRound adds the points of the round that is ending to CumulativeScores.
+// Call it before leaving the phase the round ends in.
+func scoreRound() {
+	quality := QualityLeaderboard()
+
+	participants := make(map[int32]bool)
+	for userId := range Submissions {
+		participants[userId] = true
+	}
+
+	round := make(map[int32]CumulativeScore)
+	for _, entry := range quality {
+		score := round[entry.AuthorId]
+		score.QualityPoints = entry.AdjustedStars * qualityPointsPerStar
+		round[entry.AuthorId] = score
+	}
+	for userId := range participants {
+		score := round[userId]
+		// no one could review in a round that ended before its review phase
+		if cycleState.Cycle != Coding {
+			missing := max(RequiredReviewCount(userId)-CompletedReviewCount(userId), 0)
+			score.ReviewPenalty = -float64(missing) * Settings.QuotaPenalty
+		}
+		round[userId] = score
+	}
+	for _, sub := range Submissions {
+		for _, review := range sub.CodeReviews {
+			if review.Helpful >= helpfulThreshold && CountsTowardsQuota(review) {
+				score := round[review.ReviewerId]
+				score.ReviewBonus += Settings.HelpfulBonus
+				round[review.ReviewerId] = score
+			}
+		}
+	}
+
+	for userId, score := range round {
+		if !IsValidUserId(userId) {
+			continue
+		}
+		total := CumulativeScores[userId]
+		total.QualityPoints += score.QualityPoints
+		total.ReviewPenalty += score.ReviewPenalty
+		total.ReviewBonus += score.ReviewBonus
+		total.Points = total.QualityPoints + total.ReviewPenalty + total.ReviewBonus
+		if participants[userId] {
+			total.Rounds++
+		}
+		CumulativeScores[userId] = total
+	}
+}
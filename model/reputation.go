@@ -0,0 +1,181 @@
+package model
+
+import (
+	"math"
+	"sort"
+)
+
+// ArchivedReview is the part of a code review that is kept once its round is over.
+type ArchivedReview struct {
+	Round      uint32
+	AuthorId   int32
+	ReviewerId int32
+	Stars      uint8
+}
+
+// calibrationPrior is how many reviews' worth of weight the population average
+// gets when estimating a reviewer's habits, so a single review can't make
+// someone look extremely harsh or generous.
+const calibrationPrior = 3.0
+
+// minCalibrationStdDev keeps reviewers who always give the same rating from
+// blowing up their normalized scores.
+const minCalibrationStdDev = 0.5
+
+type ReviewerStats struct {
+	ReviewerId  int32
+	ReviewCount int
+	MeanStars   float64 // shrunk towards the population mean
+	StdDev      float64 // shrunk towards the population spread
+	Agreement   float64 // 0 to 1, how close the reviewer's stars are to the other reviewers'
+	Weight      float64 // how much the reviewer's ratings count on the quality leaderboard
+}
+
+func archiveReviews() {
+	for authorId, sub := range Submissions {
+		for _, review := range VisibleReviews(sub.CodeReviews) {
+			ReviewHistory = append(ReviewHistory, ArchivedReview{
+				Round:      cycleState.round,
+				AuthorId:   authorId,
+				ReviewerId: review.ReviewerId,
+				Stars:      review.Stars,
+			})
+		}
+	}
+}
+
+// allReviews returns the archived reviews together with the current round's.
+func allReviews() []ArchivedReview {
+	reviews := make([]ArchivedReview, len(ReviewHistory))
+	copy(reviews, ReviewHistory)
+	for authorId, sub := range Submissions {
+		for _, review := range VisibleReviews(sub.CodeReviews) {
+			reviews = append(reviews, ArchivedReview{
+				Round:      cycleState.round,
+				AuthorId:   authorId,
+				ReviewerId: review.ReviewerId,
+				Stars:      review.Stars,
+			})
+		}
+	}
+	return reviews
+}
+
+// ReviewerReputation calibrates every reviewer against all reviews given so far,
+// across rounds. Returns the population mean and spread alongside.
+func ReviewerReputation() (map[int32]ReviewerStats, float64, float64) {
+	reviews := allReviews()
+	stats := make(map[int32]ReviewerStats)
+	if len(reviews) == 0 {
+		return stats, 0, 0
+	}
+
+	var total float64
+	for _, review := range reviews {
+		total += float64(review.Stars)
+	}
+	globalMean := total / float64(len(reviews))
+	var squares float64
+	for _, review := range reviews {
+		squares += math.Pow(float64(review.Stars)-globalMean, 2)
+	}
+	globalStdDev := max(math.Sqrt(squares/float64(len(reviews))), minCalibrationStdDev)
+
+	type submissionKey struct {
+		round    uint32
+		authorId int32
+	}
+	bySubmission := make(map[submissionKey][]ArchivedReview)
+	byReviewer := make(map[int32][]ArchivedReview)
+	for _, review := range reviews {
+		key := submissionKey{review.Round, review.AuthorId}
+		bySubmission[key] = append(bySubmission[key], review)
+		byReviewer[review.ReviewerId] = append(byReviewer[review.ReviewerId], review)
+	}
+
+	for reviewerId, given := range byReviewer {
+		var entry ReviewerStats
+		entry.ReviewerId = reviewerId
+		entry.ReviewCount = len(given)
+
+		var sum float64
+		for _, review := range given {
+			sum += float64(review.Stars)
+		}
+		n := float64(len(given))
+		entry.MeanStars = (sum + calibrationPrior*globalMean) / (n + calibrationPrior)
+
+		var deviations float64
+		for _, review := range given {
+			deviations += math.Pow(float64(review.Stars)-entry.MeanStars, 2)
+		}
+		variance := (deviations + calibrationPrior*globalStdDev*globalStdDev) / (n + calibrationPrior)
+		entry.StdDev = max(math.Sqrt(variance), minCalibrationStdDev)
+
+		// agreement is 1 when the reviewer matches the other reviewers' mean
+		// exactly and 0 when they are the whole 1-5 scale apart
+		var agreement, compared float64
+		for _, review := range given {
+			var othersTotal, othersCount float64
+			for _, other := range bySubmission[submissionKey{review.Round, review.AuthorId}] {
+				if other.ReviewerId != reviewerId {
+					othersTotal += float64(other.Stars)
+					othersCount++
+				}
+			}
+			if othersCount == 0 {
+				continue
+			}
+			consensus := othersTotal / othersCount
+			agreement += 1 - math.Abs(float64(review.Stars)-consensus)/4
+			compared++
+		}
+		// reviewers without anything to compare against start in the middle
+		entry.Agreement = (agreement + calibrationPrior*0.5) / (compared + calibrationPrior)
+		entry.Weight = max(entry.Agreement, 0.1)
+
+		stats[reviewerId] = entry
+	}
+	return stats, globalMean, globalStdDev
+}
+
+// AdjustedStars maps a reviewer's rating onto the population's scale, so a 5
+// from someone who gives everyone 5 counts as an average rating.
+func AdjustedStars(stars uint8, reviewer ReviewerStats, globalMean float64, globalStdDev float64) float64 {
+	z := (float64(stars) - reviewer.MeanStars) / reviewer.StdDev
+	return min(max(globalMean+z*globalStdDev, 1), 5)
+}
+
+// WeightedAdjustedStars averages the calibrated ratings of reviews, weighted by
+// each reviewer's reputation. Returns 0 if there are no reviews.
+func WeightedAdjustedStars(reviews []CodeReview, stats map[int32]ReviewerStats, globalMean float64, globalStdDev float64) float64 {
+	var total, weights float64
+	for _, review := range reviews {
+		reviewer, ok := stats[review.ReviewerId]
+		if !ok {
+			continue
+		}
+		total += reviewer.Weight * AdjustedStars(review.Stars, reviewer, globalMean, globalStdDev)
+		weights += reviewer.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return total / weights
+}
+
+// SortedReviewerReputation returns ReviewerReputation as a list, most trusted first.
+func SortedReviewerReputation() []ReviewerStats {
+	stats, _, _ := ReviewerReputation()
+	list := make([]ReviewerStats, 0, len(stats))
+	for _, entry := range stats {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Weight != list[j].Weight {
+			return list[i].Weight > list[j].Weight
+		}
+		return list[i].ReviewCount > list[j].ReviewCount
+	})
+	return list
+}
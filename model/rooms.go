@@ -0,0 +1,146 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	mrand "math/rand/v2"
+	"slices"
+)
+
+// Room is a contest of its own, with its problems, schedule, phases, users,
+// submissions and leaderboards. The package level state is the state of the
+// room last selected with UseRoom, the other rooms keep theirs in roomState.
+type Room struct {
+	Id    string
+	state roomState
+}
+
+// roomState is everything a room doesn't share with the other rooms.
+type roomState struct {
+	users             map[int32]User
+	submissions       map[int32]Submission
+	reviewAssignments map[int32][]int32
+	reviewHistory     []ArchivedReview
+	cumulativeScores  map[int32]CumulativeScore
+	problemList       []Problem
+	settings          RoundSettings
+	cycleState        CycleState
+	attempts          []Attempt
+	errataLog         []Errata
+	notifications     map[int32][]Notification
+	problemsReload    ProblemReload
+	pinnedProblem     *Problem
+	schedule          Schedule
+	scheduleQueue     []string
+	schedulePos       int
+	scheduleRand      *mrand.Rand
+	similarityReport  []SimilarityPair
+	pseudonyms        map[int32]string
+	pseudonymPool     []string
+}
+
+var Rooms map[string]*Room // LOOKUP BY ROOM ID
+
+// the room whose state is the package level state
+var currentRoom *Room
+
+// AddRoom creates an empty room with the default round settings and selects
+// it. The caller must hold Mutex.
+func AddRoom(id string) (*Room, error) {
+	if _, ok := Rooms[id]; ok {
+		return nil, fmt.Errorf("room '%s' already exists", id)
+	}
+	room := &Room{Id: id}
+	Rooms[id] = room
+	UseRoom(room)
+	resetRoom()
+	return room, nil
+}
+
+// UseRoom makes the state of room the package level state, the state of the
+// room selected before is put aside until it is selected again. The caller
+// must hold Mutex, and keep holding it for as long as it works on the room.
+func UseRoom(room *Room) {
+	if room == currentRoom {
+		return
+	}
+	if currentRoom != nil {
+		currentRoom.state = roomState{
+			users:             Users,
+			submissions:       Submissions,
+			reviewAssignments: ReviewAssignments,
+			reviewHistory:     ReviewHistory,
+			cumulativeScores:  CumulativeScores,
+			problemList:       ProblemList,
+			settings:          Settings,
+			cycleState:        cycleState,
+			attempts:          Attempts,
+			errataLog:         ErrataLog,
+			notifications:     Notifications,
+			problemsReload:    ProblemsReload,
+			pinnedProblem:     pinnedProblem,
+			schedule:          CurrentSchedule,
+			scheduleQueue:     scheduleQueue,
+			schedulePos:       schedulePos,
+			scheduleRand:      scheduleRand,
+			similarityReport:  SimilarityReport,
+			pseudonyms:        pseudonyms,
+			pseudonymPool:     pseudonymPool,
+		}
+	}
+	state := room.state
+	Users = state.users
+	Submissions = state.submissions
+	ReviewAssignments = state.reviewAssignments
+	ReviewHistory = state.reviewHistory
+	CumulativeScores = state.cumulativeScores
+	ProblemList = state.problemList
+	Settings = state.settings
+	cycleState = state.cycleState
+	Attempts = state.attempts
+	ErrataLog = state.errataLog
+	Notifications = state.notifications
+	ProblemsReload = state.problemsReload
+	pinnedProblem = state.pinnedProblem
+	CurrentSchedule = state.schedule
+	scheduleQueue = state.scheduleQueue
+	schedulePos = state.schedulePos
+	scheduleRand = state.scheduleRand
+	SimilarityReport = state.similarityReport
+	pseudonyms = state.pseudonyms
+	pseudonymPool = state.pseudonymPool
+	// the room's own copy is stale until it is put aside again
+	room.state = roomState{}
+	currentRoom = room
+}
+
+// RoomList returns the rooms sorted by id.
+func RoomList() []*Room {
+	var list []*Room
+	for _, id := range slices.Sorted(maps.Keys(Rooms)) {
+		list = append(list, Rooms[id])
+	}
+	return list
+}
+
+// DefaultRoom returns the room served by the routes without a room id: the
+// room "main" if there is one, else the first room by id.
+func DefaultRoom() *Room {
+	if room, ok := Rooms[DefaultRoomId]; ok {
+		return room
+	}
+	if list := RoomList(); len(list) > 0 {
+		return list[0]
+	}
+	return nil
+}
+
+const DefaultRoomId = "main"
+
+// TickRooms moves every room along its phases. The caller must hold Mutex.
+func TickRooms() {
+	for _, room := range RoomList() {
+		UseRoom(room)
+		Tick()
+	}
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"math"
+)
+
+// CurrentRubric returns the rubric reviews are scored against this round, or nil
+// if reviews only give a plain star rating.
+func CurrentRubric() []RubricCriterion {
+	if len(Settings.Rubric) > 0 {
+		return Settings.Rubric
+	}
+	if len(ProblemList) == 0 {
+		return nil
+	}
+	return GetCurrentProblem().Rubric
+}
+
+// ValidateRubric checks that criteria have unique names, a scale of at least 2
+// and a positive weight.
+func ValidateRubric(rubric []RubricCriterion) error {
+	seen := make(map[string]bool)
+	for _, criterion := range rubric {
+		if criterion.Name == "" {
+			return fmt.Errorf("rubric criterion is missing a 'Name'")
+		}
+		if seen[criterion.Name] {
+			return fmt.Errorf("rubric criterion '%s' is defined twice", criterion.Name)
+		}
+		seen[criterion.Name] = true
+		// a single possible score says nothing about the submission
+		if criterion.Scale < 2 {
+			return fmt.Errorf("rubric criterion '%s' needs a 'Scale' of at least 2", criterion.Name)
+		}
+		if criterion.Weight <= 0 {
+			return fmt.Errorf("rubric criterion '%s' needs a positive 'Weight'", criterion.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateScores checks that scores holds an in-range score for every criterion
+// of the rubric and nothing else.
+func ValidateScores(rubric []RubricCriterion, scores map[string]uint8) error {
+	for _, criterion := range rubric {
+		score, ok := scores[criterion.Name]
+		if !ok {
+			return fmt.Errorf("missing score for '%s'", criterion.Name)
+		}
+		if score < 1 || score > criterion.Scale {
+			return fmt.Errorf("score for '%s' must be between 1 and %d", criterion.Name, criterion.Scale)
+		}
+	}
+	if len(scores) != len(rubric) {
+		return fmt.Errorf("scores contain criteria that are not part of the rubric")
+	}
+	return nil
+}
+
+// StarsFromScores combines rubric scores into a weighted 1 to 5 star rating.
+func StarsFromScores(rubric []RubricCriterion, scores map[string]uint8) uint8 {
+	var total, weights float64
+	for _, criterion := range rubric {
+		score, ok := scores[criterion.Name]
+		if !ok || criterion.Scale < 2 {
+			continue
+		}
+		total += criterion.Weight * float64(score-1) / float64(criterion.Scale-1)
+		weights += criterion.Weight
+	}
+	if weights == 0 {
+		return 5
+	}
+	return uint8(math.Round(1 + 4*total/weights))
+}
+
+// AverageStars returns the mean star rating of reviews, or 0 if there are none.
+func AverageStars(reviews []CodeReview) float64 {
+	if len(reviews) == 0 {
+		return 0
+	}
+	var total float64
+	for _, review := range reviews {
+		total += float64(review.Stars)
+	}
+	return total / float64(len(reviews))
+}
+
+// CriterionAverages returns the mean score per rubric criterion over reviews.
+// Criteria that no review scored are left out.
+func CriterionAverages(reviews []CodeReview) map[string]float64 {
+	totals := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, review := range reviews {
+		for name, score := range review.Scores {
+			totals[name] += float64(score)
+			counts[name]++
+		}
+	}
+
+	averages := make(map[string]float64)
+	for name, total := range totals {
+		averages[name] = total / float64(counts[name])
+	}
+	return averages
+}
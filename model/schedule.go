@@ -0,0 +1,249 @@
+package model
+
+import (
+	"fmt"
+	mrand "math/rand/v2"
+	"slices"
+	"time"
+)
+
+type ScheduleOrder int
+
+const (
+	FileOrder       = iota // the order the problems were loaded in
+	PlaylistOrder          // the problems named by the schedule's Playlist, in that order
+	DifficultyOrder        // easiest first, in file order within a difficulty
+	RandomOrder            // drawn at random without repeats
+)
+
+// ExhaustedAction is what happens once every scheduled problem was played.
+type ExhaustedAction int
+
+const (
+	LoopSchedule      = iota // play the same order again
+	StopSchedule             // stay on the results of the last problem
+	ReshuffleSchedule        // play every problem again in a new random order
+)
+
+// PhaseDurations overrides how long a problem's phases last. Zero keeps the default.
+type PhaseDurations struct {
+	CodingMins float64
+	ReviewMins float64
+}
+
+type Schedule struct {
+	Order         ScheduleOrder
+	Playlist      []string // problem names, PlaylistOrder only
+	Seed          uint64   // of the random draws, 0 picks a random seed
+	WhenExhausted ExhaustedAction
+	Overrides     map[string]PhaseDurations // LOOKUP BY PROBLEM NAME
+
+	DifficultyDurations map[ProblemDifficulty]PhaseDurations // LOOKUP BY DIFFICULTY, defaults of the problems
+
+	Start    time.Time      // zero to start right away
+	End      time.Time      // zero for no end
+	Location *time.Location // time zone of Start and End, nil for the server's
+}
+
+var CurrentSchedule Schedule
+
+// the names of the problems in the order they are played this pass
+var scheduleQueue []string
+
+// position of the problem in play in scheduleQueue
+var schedulePos int
+
+var scheduleRand *mrand.Rand
+
+// scheduledNames returns the names of the problems in ProblemList in the
+// order of the schedule.
+func scheduledNames() []string {
+	var names []string
+	if CurrentSchedule.Order == PlaylistOrder {
+		for _, name := range CurrentSchedule.Playlist {
+			if FindProblem(name) != nil {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+
+	problems := slices.Clone(ProblemList)
+	if CurrentSchedule.Order == DifficultyOrder {
+		slices.SortStableFunc(problems, func(a Problem, b Problem) int {
+			return int(a.Difficulty) - int(b.Difficulty)
+		})
+	}
+	for _, problem := range problems {
+		names = append(names, problem.Header.Name)
+	}
+	if CurrentSchedule.Order == RandomOrder {
+		shuffleNames(names)
+	}
+	return names
+}
+
+func shuffleNames(names []string) {
+	scheduleRand.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+}
+
+// FindProblem returns the problem named name, or nil if there is none.
+func FindProblem(name string) *Problem {
+	for i := range ProblemList {
+		if ProblemList[i].Header.Name == name {
+			return &ProblemList[i]
+		}
+	}
+	return nil
+}
+
+// SetSchedule starts schedule from its first problem. Playlist entries must
+// name loaded problems.
+func SetSchedule(schedule Schedule) error {
+	for _, name := range schedule.Playlist {
+		if FindProblem(name) == nil {
+			return fmt.Errorf("playlist entry '%s' is not a loaded problem", name)
+		}
+	}
+	for name := range schedule.Overrides {
+		if FindProblem(name) == nil {
+			return fmt.Errorf("override of '%s', which is not a loaded problem", name)
+		}
+	}
+	if schedule.Order == PlaylistOrder && len(schedule.Playlist) == 0 {
+		return fmt.Errorf("the playlist is empty")
+	}
+	if err := validateContestTimes(schedule); err != nil {
+		return err
+	}
+
+	if schedule.Seed == 0 {
+		schedule.Seed = mrand.Uint64()
+	}
+	scheduleRand = mrand.New(mrand.NewPCG(schedule.Seed, 0))
+	CurrentSchedule = schedule
+	scheduleQueue = scheduledNames()
+	schedulePos = 0
+	cycleState.finished = false
+	pinnedProblem = nil
+	if len(scheduleQueue) > 0 {
+		setCurrentProblem(scheduleQueue[0])
+	}
+	if !schedule.Start.IsZero() {
+		if time.Now().Before(schedule.Start) {
+			cycleState.Cycle = Lobby
+		} else {
+			startContest()
+		}
+	}
+	return nil
+}
+
+func setCurrentProblem(name string) {
+	for i := range ProblemList {
+		if ProblemList[i].Header.Name == name {
+			cycleState.currentProblemIdx = uint32(i)
+			loadProblemData(&ProblemList[i])
+		}
+	}
+}
+
+// nextScheduledProblem moves on to the next problem of the schedule. Returns
+// false once the schedule is exhausted and says to stop.
+func nextScheduledProblem() bool {
+	// problems removed by a reload are skipped
+	for skipped := 0; skipped <= len(scheduleQueue); skipped++ {
+		schedulePos++
+		if schedulePos >= len(scheduleQueue) {
+			switch CurrentSchedule.WhenExhausted {
+			case StopSchedule:
+				schedulePos = len(scheduleQueue) - 1
+				return false
+			case ReshuffleSchedule:
+				scheduleQueue = scheduledNames()
+				if CurrentSchedule.Order != RandomOrder {
+					shuffleNames(scheduleQueue)
+				}
+			}
+			schedulePos = 0
+		}
+		if len(scheduleQueue) == 0 {
+			return false
+		}
+		if FindProblem(scheduleQueue[schedulePos]) != nil {
+			setCurrentProblem(scheduleQueue[schedulePos])
+			return true
+		}
+	}
+	return false
+}
+
+// refreshSchedule fits the rest of the schedule to a reloaded problem list:
+// the problems still to be played are ordered again, with new problems included.
+func refreshSchedule() {
+	if len(scheduleQueue) == 0 {
+		scheduleQueue = scheduledNames()
+		schedulePos = 0
+		return
+	}
+
+	played := scheduleQueue[:schedulePos+1]
+	var upcoming []string
+	if CurrentSchedule.Order == RandomOrder {
+		// keep the draws already made, new problems are drawn among the rest
+		upcoming = slices.Clone(scheduleQueue[schedulePos+1:])
+		var added []string
+		for _, problem := range ProblemList {
+			if !slices.Contains(scheduleQueue, problem.Header.Name) {
+				added = append(added, problem.Header.Name)
+			}
+		}
+		shuffleNames(added)
+		for _, name := range added {
+			at := scheduleRand.IntN(len(upcoming) + 1)
+			upcoming = slices.Insert(upcoming, at, name)
+		}
+	} else {
+		for _, name := range scheduledNames() {
+			if !slices.Contains(played, name) {
+				upcoming = append(upcoming, name)
+			}
+		}
+	}
+	scheduleQueue = append(slices.Clone(played), upcoming...)
+}
+
+// scheduleExhausted reports whether the problem in play is the last one of a
+// schedule that stops once exhausted.
+func scheduleExhausted() bool {
+	if CurrentSchedule.WhenExhausted != StopSchedule {
+		return false
+	}
+	for _, name := range UpcomingProblems() {
+		if FindProblem(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// finishSchedule scores the last round and stays on its results.
+func finishSchedule() {
+	scoreRound()
+	cycleState.Cycle = Finished
+	cycleState.finished = true
+}
+
+// UpcomingProblems returns the names of the problems left to play this pass,
+// the problem in play excluded.
+func UpcomingProblems() []string {
+	if schedulePos+1 >= len(scheduleQueue) {
+		return nil
+	}
+	return slices.Clone(scheduleQueue[schedulePos+1:])
+}
+
+// ScheduleFinished reports whether the schedule was exhausted and stopped.
+func ScheduleFinished() bool {
+	return cycleState.finished
+}
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"hash/fnv"
+	"sort"
+	"unicode"
+)
+
+// Fingerprinting follows the winnowing scheme: hash every run of
+// similarityKGram normalized tokens and keep the smallest hash of every
+// window of similarityWindow consecutive hashes. Any match of at least
+// similarityKGram+similarityWindow-1 tokens is guaranteed to be found.
+const similarityKGram = 5
+const similarityWindow = 4
+
+// similarityKeywords are kept as-is when normalizing, every other identifier
+// becomes the same token so that renaming variables doesn't hide a copy.
+var similarityKeywords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "do": true, "switch": true,
+	"case": true, "default": true, "break": true, "continue": true, "return": true,
+	"func": true, "function": true, "def": true, "class": true, "struct": true,
+	"var": true, "let": true, "const": true, "int": true, "float": true, "double": true,
+	"char": true, "bool": true, "void": true, "string": true, "new": true, "delete": true,
+	"try": true, "catch": true, "except": true, "finally": true, "throw": true, "raise": true,
+	"import": true, "from": true, "package": true, "in": true, "not": true, "and": true,
+	"or": true, "range": true, "lambda": true, "yield": true, "static": true, "public": true,
+	"private": true, "true": true, "false": true, "null": true, "nil": true, "None": true,
+}
+
+type sourceToken struct {
+	text string
+	file int
+	line int
+}
+
+type CodeRegion struct {
+	File      string
+	StartLine int
+	EndLine   int
+}
+
+type SimilarityPair struct {
+	AuthorA    int32
+	AuthorB    int32
+	Similarity float64 // shared fingerprints over all fingerprints of both, 0 to 1
+	Flagged    bool
+	RegionsA   []CodeRegion // parts of A's code that also appear in B's
+	RegionsB   []CodeRegion // parts of B's code that also appear in A's
+}
+
+var SimilarityReport []SimilarityPair // most similar first, computed at the end of the coding phase
+
+// tokenizeSource splits code into normalized tokens. Comments and whitespace are
+// dropped, non-keyword identifiers become "id", numbers "num" and strings "str".
+func tokenizeSource(code string, file int) []sourceToken {
+	var tokens []sourceToken
+	runes := []rune(code)
+	line := 1
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case c == '#' || (c == '/' && i+1 < len(runes) && runes[i+1] == '/'):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+		case c == '"' || c == '\'' || c == '`':
+			start := line
+			i++
+			for i < len(runes) && runes[i] != c {
+				if runes[i] == '\\' {
+					i++
+				} else if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i++
+			tokens = append(tokens, sourceToken{"str", file, start})
+		case unicode.IsDigit(c):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, sourceToken{"num", file, line})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			word := string(runes[start:i])
+			if !similarityKeywords[word] {
+				word = "id"
+			}
+			tokens = append(tokens, sourceToken{word, file, line})
+		default:
+			tokens = append(tokens, sourceToken{string(c), file, line})
+			i++
+		}
+	}
+	return tokens
+}
+
+// winnow returns the selected fingerprint hashes of tokens, each with the
+// index of the first token of its k-gram.
+func winnow(tokens []sourceToken) map[uint64][]int {
+	fingerprints := make(map[uint64][]int)
+	if len(tokens) < similarityKGram {
+		return fingerprints
+	}
+
+	hashes := make([]uint64, len(tokens)-similarityKGram+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, token := range tokens[i : i+similarityKGram] {
+			h.Write([]byte(token.text))
+			h.Write([]byte{0})
+		}
+		hashes[i] = h.Sum64()
+	}
+
+	window := min(similarityWindow, len(hashes))
+	lastPicked := -1
+	for start := 0; start+window <= len(hashes); start++ {
+		picked := start
+		for i := start; i < start+window; i++ {
+			// rightmost minimum, so that runs of equal hashes don't re-pick
+			if hashes[i] <= hashes[picked] {
+				picked = i
+			}
+		}
+		if picked != lastPicked {
+			fingerprints[hashes[picked]] = append(fingerprints[hashes[picked]], picked)
+			lastPicked = picked
+		}
+	}
+	return fingerprints
+}
+
+// matchedRegions turns the token positions of shared fingerprints into merged line ranges.
+func matchedRegions(source []SourceFile, tokens []sourceToken, positions []int) []CodeRegion {
+	type span struct{ file, start, end int }
+	var spans []span
+	for _, pos := range positions {
+		first := tokens[pos]
+		last := tokens[pos+similarityKGram-1]
+		if first.file != last.file {
+			last = first
+		}
+		spans = append(spans, span{first.file, first.line, max(last.line, first.line)})
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].file != spans[j].file {
+			return spans[i].file < spans[j].file
+		}
+		return spans[i].start < spans[j].start
+	})
+
+	var regions []CodeRegion
+	for i, s := range spans {
+		if i > 0 {
+			prev := &regions[len(regions)-1]
+			if prev.File == source[s.file].Name && s.start <= prev.EndLine+1 {
+				prev.EndLine = max(prev.EndLine, s.end)
+				continue
+			}
+		}
+		regions = append(regions, CodeRegion{File: source[s.file].Name, StartLine: s.start, EndLine: s.end})
+	}
+	return regions
+}
+
+// starterFingerprints returns the fingerprints of the current problem's
+// starter templates, code every submission may share.
+func starterFingerprints() map[uint64]bool {
+	fingerprints := make(map[uint64]bool)
+	if len(ProblemList) == 0 {
+		return fingerprints
+	}
+	for _, starter := range GetCurrentProblem().Starters {
+		for hash := range winnow(tokenizeSource(starter.Code, 0)) {
+			fingerprints[hash] = true
+		}
+	}
+	return fingerprints
+}
+
+// RunSimilarityCheck compares every pair of submissions in the current round
+// and stores the result in SimilarityReport. Code from the starter templates
+// doesn't count.
+func RunSimilarityCheck() {
+	type fingerprinted struct {
+		authorId     int32
+		source       []SourceFile
+		tokens       []sourceToken
+		fingerprints map[uint64][]int
+	}
+
+	starters := starterFingerprints()
+	var subs []fingerprinted
+	for authorId, sub := range Submissions {
+		var tokens []sourceToken
+		for i, file := range sub.Source {
+			tokens = append(tokens, tokenizeSource(file.Code, i)...)
+		}
+		fingerprints := winnow(tokens)
+		for hash := range starters {
+			delete(fingerprints, hash)
+		}
+		subs = append(subs, fingerprinted{authorId, sub.Source, tokens, fingerprints})
+	}
+
+	SimilarityReport = nil
+	for i := 0; i < len(subs); i++ {
+		for j := i + 1; j < len(subs); j++ {
+			a, b := subs[i], subs[j]
+			var positionsA, positionsB []int
+			shared := 0
+			for hash, posA := range a.fingerprints {
+				if posB, ok := b.fingerprints[hash]; ok {
+					shared++
+					positionsA = append(positionsA, posA...)
+					positionsB = append(positionsB, posB...)
+				}
+			}
+			if shared == 0 {
+				continue
+			}
+
+			var pair SimilarityPair
+			pair.AuthorA = a.authorId
+			pair.AuthorB = b.authorId
+			pair.Similarity = float64(shared) / float64(len(a.fingerprints)+len(b.fingerprints)-shared)
+			pair.Flagged = pair.Similarity >= Settings.SimilarityThreshold
+			if pair.Flagged {
+				pair.RegionsA = matchedRegions(a.source, a.tokens, positionsA)
+				pair.RegionsB = matchedRegions(b.source, b.tokens, positionsB)
+			}
+			SimilarityReport = append(SimilarityReport, pair)
+		}
+	}
+
+	sort.Slice(SimilarityReport, func(i, j int) bool {
+		return SimilarityReport[i].Similarity > SimilarityReport[j].Similarity
+	})
+}
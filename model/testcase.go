@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type TestCaseMode int
+
+const (
+	JSONMode = iota // Input and OutputJSON are JSON objects
+	TextMode        // Input and OutputText are plain stdin/stdout text
+)
+
+type TextComparison int
+
+const (
+	WhitespaceComparison = iota // runs of spaces and tabs match each other, line ends are trimmed
+	ExactComparison             // lines must match exactly
+	TokenComparison             // only the sequence of whitespace separated tokens matters
+)
+
+// normalizeLines splits text into lines, ignoring carriage returns and
+// trailing empty lines so that a missing or extra final newline never matters.
+func normalizeLines(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func (tc *TestCase) equal(a, b string) bool {
+	if tc.CaseSensitive {
+		return a == b
+	}
+	return strings.EqualFold(a, b)
+}
+
+// compareText compares a program's output to the expected text of a TextMode test case.
+func (tc *TestCase) compareText(output string) bool {
+	if tc.Comparison == TokenComparison {
+		expected, actual := strings.Fields(tc.OutputText), strings.Fields(output)
+		if len(expected) != len(actual) {
+			return false
+		}
+		for i := range expected {
+			if !tc.equal(expected[i], actual[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	expected, actual := normalizeLines(tc.OutputText), normalizeLines(output)
+	if tc.Comparison == WhitespaceComparison {
+		for len(expected) > 0 && strings.TrimSpace(expected[len(expected)-1]) == "" {
+			expected = expected[:len(expected)-1]
+		}
+		for len(actual) > 0 && strings.TrimSpace(actual[len(actual)-1]) == "" {
+			actual = actual[:len(actual)-1]
+		}
+	}
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		a, b := expected[i], actual[i]
+		if tc.Comparison == WhitespaceComparison {
+			a, b = strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " ")
+		}
+		if !tc.equal(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckOutput reports whether output solves the test case. JSONMode test cases
+// expect a JSON object, TextMode ones a string.
+func (tc *TestCase) CheckOutput(output interface{}) (bool, error) {
+	if err := tc.LoadData(); err != nil {
+		return false, err
+	}
+
+	if tc.Mode == TextMode {
+		text, ok := output.(string)
+		if !ok {
+			return false, fmt.Errorf("this test case expects its output as a string")
+		}
+		return tc.compareText(text), nil
+	}
+
+	expected, err := json.Marshal(tc.OutputJSON)
+	if err != nil {
+		return false, err
+	}
+	actual, err := json.Marshal(output)
+	if err != nil {
+		return false, err
+	}
+	return tc.equal(string(actual), string(expected)), nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// TestDataFile is test case data kept in a file next to the problem file
+// instead of inline, read only when a test case first needs it.
+type TestDataFile struct {
+	FS     fs.FS  // the directory of the problem file
+	Path   string // slash separated, relative to FS
+	SHA256 string // hex SHA-256 of the file's contents
+}
+
+// Read returns the file's contents after checking them against SHA256.
+func (f *TestDataFile) Read() ([]byte, error) {
+	data, err := fs.ReadFile(f.FS, f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test data '%s': %w", f.Path, err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != strings.ToLower(f.SHA256) {
+		return nil, fmt.Errorf("test data '%s' does not match its SHA-256", f.Path)
+	}
+	return data, nil
+}
+
+// LoadData reads the test case's data files, if it has any, into Input and
+// its expected output. The caller must hold Mutex.
+func (tc *TestCase) LoadData() error {
+	if tc.dataLoaded {
+		return nil
+	}
+
+	if tc.InputFile != nil {
+		data, err := tc.InputFile.Read()
+		if err != nil {
+			return err
+		}
+		if tc.Mode == JSONMode {
+			var compact bytes.Buffer
+			if err = json.Compact(&compact, data); err != nil {
+				return fmt.Errorf("test data '%s' is not valid JSON: %w", tc.InputFile.Path, err)
+			}
+			data = compact.Bytes()
+		}
+		tc.Input = string(data)
+	}
+
+	if tc.OutputFile != nil {
+		data, err := tc.OutputFile.Read()
+		if err != nil {
+			return err
+		}
+		if tc.Mode == JSONMode {
+			if err = json.Unmarshal(data, &tc.OutputJSON); err != nil {
+				return fmt.Errorf("test data '%s' is not a JSON object: %w", tc.OutputFile.Path, err)
+			}
+		} else {
+			tc.OutputText = string(data)
+		}
+	}
+
+	tc.dataLoaded = true
+	return nil
+}
@@ -0,0 +1,101 @@
+package model
+
+import "time"
+
+// TimelinePhase is a phase of a round, past, in progress or to come.
+type TimelinePhase struct {
+	Cycle CycleTime
+	Start time.Time
+	End   time.Time
+}
+
+type TimelineRound struct {
+	Round   uint32 // starting at 1
+	Problem *Problem
+	Phases  []TimelinePhase
+}
+
+func minutesDuration(minutes float64) time.Duration {
+	return time.Duration(minutes * float64(time.Minute))
+}
+
+// ProblemPhaseDurations returns how long the coding and review phases of
+// problem last. The schedule's overrides come first, then the problem's own
+// durations, then the defaults of its difficulty.
+func ProblemPhaseDurations(problem *Problem) (coding time.Duration, review time.Duration) {
+	codingMins, reviewMins := cycleState.codingDurMins, cycleState.reviewDurMins
+	for _, durations := range []PhaseDurations{
+		CurrentSchedule.DifficultyDurations[problem.Difficulty],
+		problem.Durations,
+		CurrentSchedule.Overrides[problem.Header.Name],
+	} {
+		if durations.CodingMins > 0 {
+			codingMins = durations.CodingMins
+		}
+		if durations.ReviewMins > 0 {
+			reviewMins = durations.ReviewMins
+		}
+	}
+	return minutesDuration(codingMins), minutesDuration(reviewMins)
+}
+
+// currentPhaseDuration returns how long the phase in progress lasts in total.
+func currentPhaseDuration() time.Duration {
+	coding, review := ProblemPhaseDurations(GetCurrentProblem())
+	switch cycleState.Cycle {
+	case Coding:
+		return coding
+	case Review:
+		return review
+	case Results:
+		return minutesDuration(cycleState.resultsDurMins)
+	}
+	return 0
+}
+
+// roundPhases lays out the phases of a round with problem, from phase from
+// starting at start.
+func roundPhases(problem *Problem, from CycleTime, start time.Time) []TimelinePhase {
+	coding, review := ProblemPhaseDurations(problem)
+	durations := []time.Duration{Coding: coding, Review: review, Results: minutesDuration(cycleState.resultsDurMins)}
+	var phases []TimelinePhase
+	for cycle := from; cycle <= Results; cycle++ {
+		end := start.Add(durations[cycle])
+		phases = append(phases, TimelinePhase{Cycle: cycle, Start: start, End: end})
+		start = end
+	}
+	return phases
+}
+
+// Timeline lays out the phases of the current round, from the one in progress,
+// and of the rounds left in this pass through the schedule that end before the
+// contest does. Empty once the contest is over.
+func Timeline() []TimelineRound {
+	if cycleState.Cycle == Finished {
+		return nil
+	}
+
+	current := TimelineRound{Round: cycleState.round + 1, Problem: GetCurrentProblem()}
+	if cycleState.Cycle == Lobby {
+		current.Phases = roundPhases(current.Problem, Coding, CurrentSchedule.Start)
+	} else {
+		current.Phases = roundPhases(current.Problem, cycleState.Cycle, cycleState.LastCycleTime)
+	}
+	timeline := []TimelineRound{current}
+
+	end := current.Phases[len(current.Phases)-1].End
+	for _, name := range UpcomingProblems() {
+		problem := FindProblem(name)
+		if problem == nil {
+			continue
+		}
+		if !roundFits(problem, end) {
+			break
+		}
+		round := TimelineRound{Round: timeline[len(timeline)-1].Round + 1, Problem: problem}
+		round.Phases = roundPhases(problem, Coding, end)
+		end = round.Phases[len(round.Phases)-1].End
+		timeline = append(timeline, round)
+	}
+	return timeline
+}
@@ -0,0 +1,281 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ManifestFileName is the file in a submission's root that describes how to build and run it.
+const ManifestFileName = "manifest.json"
+
+// Command templates may use these placeholders, they are filled in by the judge.
+var manifestPlaceholders = []string{
+	"{entry}", // the entry file
+	"{files}", // every source file of the toolchain's language
+	"{out}",   // the path of the built executable
+}
+
+type Toolchain struct {
+	Language   string
+	Extensions []string
+	Commands   []string // executables that build and run commands may start with
+	Build      string   // default build command, empty for interpreted languages
+	Run        string   // default run command
+	Check      string   // checks that code compiles without running it, Build is used when empty
+}
+
+var Toolchains = map[string]Toolchain{
+	"c": {
+		Language:   "c",
+		Extensions: []string{".c", ".h"},
+		Commands:   []string{"gcc", "clang", "cc"},
+		Build:      "gcc -O2 -o {out} {files} -lm",
+		Run:        "{out}",
+	},
+	"cpp": {
+		Language:   "cpp",
+		Extensions: []string{".cpp", ".cc", ".cxx", ".hpp", ".h"},
+		Commands:   []string{"g++", "clang++"},
+		Build:      "g++ -O2 -std=c++17 -o {out} {files}",
+		Run:        "{out}",
+	},
+	"go": {
+		Language:   "go",
+		Extensions: []string{".go"},
+		Commands:   []string{"go"},
+		Build:      "go build -o {out} {files}",
+		Run:        "{out}",
+	},
+	"rust": {
+		Language:   "rust",
+		Extensions: []string{".rs"},
+		Commands:   []string{"rustc"},
+		Build:      "rustc -O -o {out} {entry}",
+		Run:        "{out}",
+	},
+	"java": {
+		Language:   "java",
+		Extensions: []string{".java"},
+		Commands:   []string{"javac", "java"},
+		Build:      "javac {files}",
+		Run:        "java {entry}",
+	},
+	"python": {
+		Language:   "python",
+		Extensions: []string{".py"},
+		Commands:   []string{"python3", "pypy3"},
+		Run:        "python3 {entry}",
+		Check:      "python3 -m py_compile {files}",
+	},
+	"javascript": {
+		Language:   "javascript",
+		Extensions: []string{".js", ".mjs"},
+		Commands:   []string{"node"},
+		Run:        "node {entry}",
+		Check:      "node --check {entry}",
+	},
+}
+
+// ManifestRules is how a problem restricts the build manifests of its submissions.
+type ManifestRules struct {
+	Required         bool     // submissions must contain a manifest
+	Languages        []string // allowed toolchains, any when empty
+	AllowCustomBuild bool     // manifests may replace the toolchain's build command
+	AllowCustomRun   bool     // manifests may replace the toolchain's run command
+}
+
+var DefaultManifestRules = ManifestRules{
+	AllowCustomBuild: true,
+	AllowCustomRun:   true,
+}
+
+type BuildManifest struct {
+	Language string
+	Entry    string
+	Build    string // empty to use the toolchain's default
+	Run      string // empty to use the toolchain's default
+}
+
+// CurrentManifestRules returns the manifest rules of the current problem.
+func CurrentManifestRules() ManifestRules {
+	if len(ProblemList) == 0 || GetCurrentProblem().Manifest == nil {
+		return DefaultManifestRules
+	}
+	return *GetCurrentProblem().Manifest
+}
+
+// validateCommand checks that a command template is a single plain command
+// started by one of the toolchain's executables.
+func validateCommand(command string, toolchain Toolchain) error {
+	if strings.ContainsAny(command, ";|&`$<>\\\n\r\"'") {
+		return fmt.Errorf("command '%s' may not contain shell syntax", command)
+	}
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		return fmt.Errorf("command is empty")
+	}
+	if words[0] != "{out}" && !slices.Contains(toolchain.Commands, words[0]) {
+		return fmt.Errorf("command '%s' must start with {out} or one of: %s", command, strings.Join(toolchain.Commands, ", "))
+	}
+
+	// every {...} must be a known placeholder
+	rest := command
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return fmt.Errorf("command '%s' has an unterminated placeholder", command)
+		}
+		placeholder := rest[open : open+end+1]
+		if !slices.Contains(manifestPlaceholders, placeholder) {
+			return fmt.Errorf("command '%s' uses unknown placeholder %s, valid ones are: %s", command, placeholder, strings.Join(manifestPlaceholders, " "))
+		}
+		rest = rest[open+end+1:]
+	}
+	return nil
+}
+
+// ParseBuildManifest reads and validates the manifest of a submission against
+// the toolchain registry and the current problem's rules. Returns nil if the
+// submission has no manifest and the problem doesn't require one.
+func ParseBuildManifest(sourceFiles []SourceFile) (*BuildManifest, error) {
+	rules := CurrentManifestRules()
+
+	var manifestFile *SourceFile
+	for i := range sourceFiles {
+		if sourceFiles[i].Name == ManifestFileName {
+			manifestFile = &sourceFiles[i]
+		}
+	}
+	if manifestFile == nil {
+		if rules.Required {
+			return nil, fmt.Errorf("this problem requires a %s in the submission", ManifestFileName)
+		}
+		return nil, nil
+	}
+
+	var manifest BuildManifest
+	decoder := json.NewDecoder(bytes.NewReader([]byte(manifestFile.Code)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s, expects {'Language': string, 'Entry': string, 'Build': string, 'Run': string}: %w", ManifestFileName, err)
+	}
+
+	toolchain, ok := Toolchains[manifest.Language]
+	if !ok {
+		var names []string
+		for name := range Toolchains {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("%s: unknown 'Language' '%s', valid ones are: %s", ManifestFileName, manifest.Language, strings.Join(names, ", "))
+	}
+	if len(rules.Languages) > 0 && !slices.Contains(rules.Languages, manifest.Language) {
+		return nil, fmt.Errorf("%s: this problem only accepts: %s", ManifestFileName, strings.Join(rules.Languages, ", "))
+	}
+
+	entryFound := false
+	for _, file := range sourceFiles {
+		if file.Name == manifest.Entry {
+			entryFound = true
+		}
+	}
+	if !entryFound {
+		return nil, fmt.Errorf("%s: 'Entry' '%s' is not one of the submitted files", ManifestFileName, manifest.Entry)
+	}
+	if !slices.Contains(toolchain.Extensions, strings.ToLower(path.Ext(manifest.Entry))) {
+		return nil, fmt.Errorf("%s: 'Entry' '%s' is not a %s file", ManifestFileName, manifest.Entry, manifest.Language)
+	}
+
+	if manifest.Build != "" {
+		if !rules.AllowCustomBuild {
+			return nil, fmt.Errorf("%s: this problem does not allow a custom 'Build' command", ManifestFileName)
+		}
+		if err := validateCommand(manifest.Build, toolchain); err != nil {
+			return nil, fmt.Errorf("%s: invalid 'Build': %w", ManifestFileName, err)
+		}
+	}
+	if manifest.Run != "" {
+		if !rules.AllowCustomRun {
+			return nil, fmt.Errorf("%s: this problem does not allow a custom 'Run' command", ManifestFileName)
+		}
+		if err := validateCommand(manifest.Run, toolchain); err != nil {
+			return nil, fmt.Errorf("%s: invalid 'Run': %w", ManifestFileName, err)
+		}
+	}
+	return &manifest, nil
+}
+
+// compileCheckTimeout bounds how long checking a single file may take.
+const compileCheckTimeout = 60 * time.Second
+
+// ErrToolchainUnavailable is returned by CheckCompiles when the language's
+// compiler isn't installed, so the code couldn't be checked at all.
+var ErrToolchainUnavailable = errors.New("toolchain is not installed")
+
+// CheckCompiles builds a single source file with its language's toolchain in a
+// temporary directory and returns the compiler output if that fails.
+func CheckCompiles(language string, fileName string, code string) error {
+	toolchain, ok := Toolchains[language]
+	if !ok {
+		return fmt.Errorf("unknown language '%s'", language)
+	}
+	command := toolchain.Check
+	if command == "" {
+		command = toolchain.Build
+	}
+	if command == "" {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "starter-check-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err = ValidateFileName(fileName); err != nil {
+		return err
+	}
+	source := filepath.Join(dir, filepath.FromSlash(fileName))
+	if err = os.MkdirAll(filepath.Dir(source), 0o755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(source, []byte(code), 0o644); err != nil {
+		return err
+	}
+
+	var args []string
+	for _, word := range strings.Fields(command) {
+		word = strings.ReplaceAll(word, "{entry}", fileName)
+		word = strings.ReplaceAll(word, "{files}", fileName)
+		word = strings.ReplaceAll(word, "{out}", filepath.Join(dir, "starter.out"))
+		args = append(args, word)
+	}
+	if _, err = exec.LookPath(args[0]); err != nil {
+		return fmt.Errorf("%w: %s", ErrToolchainUnavailable, args[0])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), compileCheckTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("'%s' failed: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+func hashSourceFiles(sourceFiles []SourceFile) string {
+	h := sha256.New()
+	for _, file := range sourceFiles {
+		h.Write([]byte(file.Name))
+		h.Write([]byte{0})
+		h.Write([]byte(file.Code))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GetSubmissionVersion returns version number of uId's submission this round.
+func GetSubmissionVersion(uId int32, number int) (*SubmissionVersion, error) {
+	sub, ok := Submissions[uId]
+	if !ok {
+		return nil, fmt.Errorf("no submission found")
+	}
+	if number < 1 || number > len(sub.Versions) {
+		return nil, fmt.Errorf("no version %d, the submission has %d versions", number, len(sub.Versions))
+	}
+	return &sub.Versions[number-1], nil
+}
+
+// SelectSubmissionVersion makes version number the one that counts for grading.
+// Number 0 goes back to counting the latest version.
+func SelectSubmissionVersion(uId int32, number int) error {
+	sub, ok := Submissions[uId]
+	if !ok || len(sub.Versions) == 0 {
+		return fmt.Errorf("no submission found")
+	}
+	if number == 0 {
+		sub.SelectedVersion = 0
+		sub.Source = sub.Versions[len(sub.Versions)-1].Source
+		sub.Manifest = sub.Versions[len(sub.Versions)-1].Manifest
+		Submissions[uId] = sub
+		return nil
+	}
+	version, err := GetSubmissionVersion(uId, number)
+	if err != nil {
+		return err
+	}
+	sub.SelectedVersion = number
+	sub.Source = version.Source
+	sub.Manifest = version.Manifest
+	Submissions[uId] = sub
+	return nil
+}
+
+// CountedVersion returns the number of the version that counts for grading.
+func CountedVersion(uId int32) int {
+	sub := Submissions[uId]
+	if sub.SelectedVersion != 0 {
+		return sub.SelectedVersion
+	}
+	return len(sub.Versions)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type Street struct {
+	Name  string
+	Nodes [][2]float64
+}
+
+// solve returns the list of directions
+func solve(streets []Street) []string {
+	// TODO
+	return []string{}
+}
+
+func main() {
+	var input struct{ Streets []Street }
+	if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil {
+		panic(err)
+	}
+	json.NewEncoder(os.Stdout).Encode(map[string][]string{"Directions": solve(input.Streets)})
+}
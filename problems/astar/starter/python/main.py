@@ -0,0 +1,17 @@
+import json
+import sys
+
+
+def solve(streets):
+    # TODO: return the list of directions
+    return []
+
+
+def main():
+    data = json.load(sys.stdin)
+    directions = solve(data["Streets"])
+    json.dump({"Directions": directions}, sys.stdout)
+
+
+if __name__ == "__main__":
+    main()
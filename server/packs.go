@@ -0,0 +1,372 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"server/model"
+	"strings"
+)
+
+// A problem pack is a directory or zip archive holding a single problem:
+//
+//	problem.json   the problem, in the same format as an entry of a problems file
+//	statement.md   optional, replaces the problem's 'Statement'
+//	statement.<language>.md  optional translations, like statement.fr.md
+//	data/          test data, referenced by 'InputFile' and 'OutputFile'
+//	checker/       optional custom output checker
+//	starter/<language>/  optional starter templates, one per file
+//	solution/      optional reference solution
+//
+// A zipped pack may also keep all of this in a single top-level directory.
+const PackProblemFile = "problem.json"
+const packStatementFile = "statement.md"
+
+// read_pack_files reads every file under dir, with names relative to dir.
+// Returns nil if dir doesn't exist.
+func read_pack_files(pack fs.FS, dir string) ([]model.SourceFile, error) {
+	if _, err := fs.Stat(pack, dir); err != nil {
+		return nil, nil
+	}
+
+	var files []model.SourceFile
+	err := fs.WalkDir(pack, dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		code, err := fs.ReadFile(pack, name)
+		if err != nil {
+			return err
+		}
+		files = append(files, model.SourceFile{Name: strings.TrimPrefix(name, dir+"/"), Code: string(code)})
+		return nil
+	})
+	return files, err
+}
+
+// read_pack_starters reads the starter/<language>/ directories of a pack.
+func read_pack_starters(pack fs.FS) ([]model.StarterTemplate, error) {
+	files, err := read_pack_files(pack, "starter")
+	if err != nil {
+		return nil, err
+	}
+
+	var starters []model.StarterTemplate
+	for _, file := range files {
+		language, fileName, found := strings.Cut(file.Name, "/")
+		if _, known := model.Toolchains[language]; !found || !known {
+			return nil, fmt.Errorf("starter '%s' must be in a directory named after its language", file.Name)
+		}
+		starter := model.StarterTemplate{Language: language, FileName: fileName, Code: file.Code}
+		if err = check_starter(starter); err != nil {
+			return nil, err
+		}
+		starters = append(starters, starter)
+	}
+	return starters, nil
+}
+
+// LoadPack reads the problem pack at the root of pack.
+func LoadPack(pack fs.FS) (*model.Problem, error) {
+	bytes, err := fs.ReadFile(pack, PackProblemFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid problem pack: %w", err)
+	}
+	var problemJSON interface{}
+	if err = json.Unmarshal(bytes, &problemJSON); err != nil {
+		return nil, fmt.Errorf("invalid problem pack: %s is not valid JSON", PackProblemFile)
+	}
+
+	problem, err := json_to_problem(problemJSON, 0, pack)
+	if err != nil {
+		return nil, err
+	}
+
+	if statement, err := fs.ReadFile(pack, packStatementFile); err == nil {
+		problem.Statement = string(statement)
+	}
+	translations, _ := fs.Glob(pack, "statement.*.md")
+	for _, name := range translations {
+		language := strings.TrimSuffix(strings.TrimPrefix(name, "statement."), ".md")
+		if err = model.ValidateLanguageTag(language); err != nil {
+			return nil, fmt.Errorf("invalid problem pack '%s': %s: %w", problem.Header.Name, name, err)
+		}
+		statement, err := fs.ReadFile(pack, name)
+		if err != nil {
+			return nil, err
+		}
+		problem.Translations[language] = model.Translation{Statement: string(statement)}
+	}
+	starters, err := read_pack_starters(pack)
+	if err != nil {
+		return nil, fmt.Errorf("invalid problem pack '%s': %w", problem.Header.Name, err)
+	}
+	problem.Starters = append(problem.Starters, starters...)
+	if problem.Checker, err = read_pack_files(pack, "checker"); err != nil {
+		return nil, err
+	}
+	if problem.Solution, err = read_pack_files(pack, "solution"); err != nil {
+		return nil, err
+	}
+	if err = load_statement(problem, pack); err != nil {
+		return nil, err
+	}
+	return problem, nil
+}
+
+// pack_revision hashes every file of a pack.
+func pack_revision(pack fs.FS) (string, error) {
+	hash := sha256.New()
+	err := fs.WalkDir(pack, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(pack, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(data))
+		hash.Write(data)
+		return nil
+	})
+	return hex.EncodeToString(hash.Sum(nil)), err
+}
+
+// parse_pack loads the problem pack at the root of pack.
+func parse_pack(pack fs.FS) ([]model.Problem, error) {
+	problem, err := LoadPack(pack)
+	if err != nil {
+		return nil, err
+	}
+	if problem.Revision, err = pack_revision(pack); err != nil {
+		return nil, err
+	}
+	return []model.Problem{*problem}, nil
+}
+
+// parse_pack_dir loads the problem pack in directory dir of fsys.
+func parse_pack_dir(fsys fs.FS, dir string) ([]model.Problem, error) {
+	pack, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return parse_pack(pack)
+}
+
+// open_pack_zip returns the root of the zipped problem pack data.
+func open_pack_zip(data []byte) (fs.FS, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid problem pack: %w", err)
+	}
+
+	var pack fs.FS = archive
+	if _, err = fs.Stat(pack, PackProblemFile); err != nil {
+		// packs are often zipped with their directory
+		entries, err := fs.ReadDir(pack, ".")
+		if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+			return nil, fmt.Errorf("invalid problem pack: no %s", PackProblemFile)
+		}
+		return fs.Sub(pack, entries[0].Name())
+	}
+	return pack, nil
+}
+
+// parse_pack_zip loads the zipped problem pack name of fsys.
+func parse_pack_zip(fsys fs.FS, name string) ([]model.Problem, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	pack, err := open_pack_zip(data)
+	if err != nil {
+		return nil, err
+	}
+	return parse_pack(pack)
+}
+
+// LoadPackPath reads the problem pack in the directory or zip archive at path.
+func LoadPackPath(path string) (*model.Problem, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return LoadPack(os.DirFS(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pack, err := open_pack_zip(data)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPack(pack)
+}
+
+var difficultyNames = map[model.ProblemDifficulty]string{
+	model.Easy:   "Easy",
+	model.Medium: "Medium",
+	model.Hard:   "Hard",
+}
+
+var comparisonNames = map[model.TextComparison]string{
+	model.WhitespaceComparison: "whitespace",
+	model.ExactComparison:      "exact",
+	model.TokenComparison:      "tokens",
+}
+
+func write_pack_file(dir string, name string, data []byte) (*model.TestDataFile, error) {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &model.TestDataFile{Path: name, SHA256: hex.EncodeToString(sum[:])}, os.WriteFile(target, data, 0o644)
+}
+
+// test_case_to_json converts a test case back to its problem.json form, the
+// data of external test cases is written into the pack's data directory.
+func test_case_to_json(testCase *model.TestCase, dir string, number int) (map[string]interface{}, error) {
+	testCaseJSON := map[string]interface{}{"CaseSensitive": testCase.CaseSensitive}
+	if testCase.Sample {
+		testCaseJSON["Sample"] = true
+	}
+	if testCase.Name != "" {
+		testCaseJSON["Name"] = testCase.Name
+	}
+	if testCase.Mode == model.TextMode {
+		testCaseJSON["Mode"] = "text"
+		testCaseJSON["Comparison"] = comparisonNames[testCase.Comparison]
+	}
+
+	if err := testCase.LoadData(); err != nil {
+		return nil, err
+	}
+	output := []byte(testCase.OutputText)
+	if testCase.Mode == model.JSONMode {
+		var err error
+		if output, err = json.Marshal(testCase.OutputJSON); err != nil {
+			return nil, err
+		}
+	}
+
+	if !testCase.External {
+		if testCase.Mode == model.TextMode {
+			testCaseJSON["Input"] = testCase.Input
+			testCaseJSON["Output"] = testCase.OutputText
+		} else {
+			testCaseJSON["Input"] = json.RawMessage(testCase.Input)
+			testCaseJSON["Output"] = json.RawMessage(output)
+		}
+		return testCaseJSON, nil
+	}
+
+	name := fmt.Sprintf("data/%03d", number)
+	if testCase.Name != "" {
+		name = "data/" + testCase.Name
+	}
+	input, err := write_pack_file(dir, name+".in", []byte(testCase.Input))
+	if err != nil {
+		return nil, err
+	}
+	answer, err := write_pack_file(dir, name+".ans", output)
+	if err != nil {
+		return nil, err
+	}
+	testCaseJSON["InputFile"] = map[string]string{"Path": input.Path, "SHA256": input.SHA256}
+	testCaseJSON["OutputFile"] = map[string]string{"Path": answer.Path, "SHA256": answer.SHA256}
+	return testCaseJSON, nil
+}
+
+// WritePack writes problem as a problem pack into dir.
+func WritePack(problem *model.Problem, dir string) error {
+	problemJSON := map[string]interface{}{
+		"Name":       problem.Header.Name,
+		"Objective":  problem.Objective,
+		"Header":     problem.Header.Description,
+		"Difficulty": difficultyNames[problem.Difficulty],
+	}
+	if problem.Header.Description == "" {
+		problemJSON["Header"] = problem.Header.Name
+	}
+	if problem.Language != "" {
+		problemJSON["Language"] = problem.Language
+	}
+	if problem.Source != "" {
+		problemJSON["Source"] = problem.Source
+	}
+	if len(problem.Rubric) > 0 {
+		problemJSON["Rubric"] = problem.Rubric
+	}
+	if problem.Durations.CodingMins > 0 {
+		problemJSON["CodingMinutes"] = problem.Durations.CodingMins
+	}
+	if problem.Durations.ReviewMins > 0 {
+		problemJSON["ReviewMinutes"] = problem.Durations.ReviewMins
+	}
+	if len(problem.AllowedExtensions) > 0 {
+		problemJSON["AllowedExtensions"] = problem.AllowedExtensions
+	}
+	if problem.Manifest != nil {
+		problemJSON["Manifest"] = problem.Manifest
+	}
+
+	testCases := make([]interface{}, 0, len(problem.TestCases))
+	for i := range problem.TestCases {
+		testCaseJSON, err := test_case_to_json(&problem.TestCases[i], dir, i+1)
+		if err != nil {
+			return err
+		}
+		testCases = append(testCases, testCaseJSON)
+	}
+	problemJSON["TestCases"] = testCases
+
+	bytes, err := json.MarshalIndent(problemJSON, "", "    ")
+	if err != nil {
+		return err
+	}
+	if _, err = write_pack_file(dir, PackProblemFile, append(bytes, '\n')); err != nil {
+		return err
+	}
+
+	if problem.Statement != "" {
+		if _, err = write_pack_file(dir, packStatementFile, []byte(problem.Statement)); err != nil {
+			return err
+		}
+	}
+	for language, translation := range problem.Translations {
+		if _, err = write_pack_file(dir, "statement."+language+".md", []byte(translation.Statement)); err != nil {
+			return err
+		}
+	}
+	for src, data := range problem.Images {
+		if _, err = write_pack_file(dir, path.Clean(strings.TrimPrefix(src, "./")), data); err != nil {
+			return err
+		}
+	}
+	for _, starter := range problem.Starters {
+		if _, err = write_pack_file(dir, "starter/"+starter.Language+"/"+starter.FileName, []byte(starter.Code)); err != nil {
+			return err
+		}
+	}
+	for _, file := range problem.Checker {
+		if _, err = write_pack_file(dir, "checker/"+file.Name, []byte(file.Code)); err != nil {
+			return err
+		}
+	}
+	for _, file := range problem.Solution {
+		if _, err = write_pack_file(dir, "solution/"+file.Name, []byte(file.Code)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
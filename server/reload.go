@@ -0,0 +1,100 @@
+package server
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"server/model"
+	"time"
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+// snapshot_problems stamps every file under dir. Files whose modification time
+// and size match previous keep their hash, the others are hashed again.
+func snapshot_problems(dir string, previous map[string]fileStamp) (map[string]fileStamp, error) {
+	stamps := make(map[string]fileStamp)
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+		if old, ok := previous[name]; ok && old.modTime.Equal(stamp.modTime) && old.size == stamp.size {
+			stamp.sum = old.sum
+		} else {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			stamp.sum = sha256.Sum256(data)
+		}
+		stamps[name] = stamp
+		return nil
+	})
+	return stamps, err
+}
+
+// problems_changed reports whether any file was added, removed or modified.
+// Files that were only touched don't count.
+func problems_changed(previous map[string]fileStamp, next map[string]fileStamp) bool {
+	return !maps.EqualFunc(previous, next, func(a fileStamp, b fileStamp) bool {
+		return a.sum == b.sum
+	})
+}
+
+// reload_problems parses the problems directory of room again and swaps the
+// new list in. If any file fails to load the current list is kept.
+func reload_problems(room *model.Room, dir string) {
+	problems, err := parse_problems(os.DirFS(dir), true)
+	if err == nil && len(problems) == 0 {
+		err = errors.New("no problems")
+	}
+
+	model.Mutex.Lock()
+	defer model.Mutex.Unlock()
+	model.UseRoom(room)
+
+	if err != nil {
+		fmt.Println("Problems of room", room.Id, "not reloaded:", err)
+		model.ProblemsReloadFailed(err)
+		return
+	}
+	model.ReplaceProblems(problems)
+	if model.ProblemsReload.ErrataPending {
+		fmt.Println("The current problem of room", room.Id, "changed, an admin can apply the errata with /api/rooms/"+room.Id+"/admin/apply_errata.")
+	}
+}
+
+// WatchProblems polls dir every interval and reloads the problems of room when
+// its files change.
+func WatchProblems(room *model.Room, dir string, interval time.Duration) {
+	stamps, _ := snapshot_problems(dir, nil)
+
+	go func() {
+		for range time.Tick(interval) {
+			next, err := snapshot_problems(dir, stamps)
+			if err != nil {
+				// most likely a file that is being replaced, try again next time
+				continue
+			}
+			changed := problems_changed(stamps, next)
+			stamps = next
+			if changed {
+				reload_problems(room, dir)
+			}
+		}
+	}()
+}
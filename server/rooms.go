@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"server/model"
+	"time"
+)
+
+// Every directory in the rooms directory is a room, named after it, with its
+// own problems directory and schedule.json:
+//
+//	rooms/
+//	    beginner/
+//	        problems/
+//	        schedule.json
+//	    advanced/
+//	        problems/
+//	        schedule.json
+//
+// Without a rooms directory there is a single room "main", of the problems
+// directory and schedule.json next to the server.
+var roomIdPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type roomDir struct {
+	id  string
+	dir string
+}
+
+// find_rooms lists the rooms of the rooms directory at dir.
+func find_rooms(dir string) ([]roomDir, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []roomDir{{id: model.DefaultRoomId, dir: "."}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var rooms []roomDir
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if !roomIdPattern.MatchString(entry.Name()) {
+			return nil, fmt.Errorf("invalid room name '%s', only letters, digits, '-' and '_' are allowed", entry.Name())
+		}
+		rooms = append(rooms, roomDir{id: entry.Name(), dir: filepath.Join(dir, entry.Name())})
+	}
+	if len(rooms) == 0 {
+		return nil, fmt.Errorf("no rooms in '%s'", dir)
+	}
+	return rooms, nil
+}
+
+// InitRooms loads the rooms of the rooms directory at dir and watches their
+// problems directories every pollInterval. Rooms without a problems directory
+// play the problems in defaults, a room that loads no problems is an error.
+func InitRooms(dir string, defaults fs.FS, pollInterval time.Duration) error {
+	rooms, err := find_rooms(dir)
+	if err != nil {
+		return err
+	}
+
+	model.Mutex.Lock()
+	defer model.Mutex.Unlock()
+
+	for _, found := range rooms {
+		fmt.Println("Room", found.id+":")
+		room, err := model.AddRoom(found.id)
+		if err != nil {
+			return err
+		}
+		problemsDir := filepath.Join(found.dir, "problems")
+		if err = InitProblems(problemsDir, defaults); err != nil {
+			return fmt.Errorf("room '%s': error parsing JSON problems file: %w", found.id, err)
+		}
+		if len(model.ProblemList) == 0 {
+			// like a reload, which keeps the old list rather than play nothing
+			return fmt.Errorf("room '%s': no problems loaded", found.id)
+		}
+		if err = InitSchedule(filepath.Join(found.dir, "schedule.json")); err != nil {
+			return fmt.Errorf("room '%s': error loading schedule.json: %w", found.id, err)
+		}
+		if info, err := os.Stat(problemsDir); err == nil && info.IsDir() {
+			// the watcher waits for Mutex, so it starts once every room is loaded
+			WatchProblems(room, problemsDir, pollInterval)
+		}
+	}
+	return nil
+}
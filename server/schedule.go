@@ -0,0 +1,211 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"server/model"
+	"time"
+	_ "time/tzdata" // for "TimeZone" on systems without a time zone database
+)
+
+// The schedule file says in which order problems are played, all fields are
+// optional:
+//
+//	{
+//	    "Order": "file" | "playlist" | "difficulty" | "random",  // default "file"
+//	    "Playlist": [problem name],  // the problems to play, "playlist" order only
+//	    "Seed": integer,  // of the random draws, a random seed if left out
+//	    "WhenExhausted": "loop" | "stop" | "reshuffle",  // default "loop"
+//	    "Overrides": {problem name: {"CodingMinutes": number, "ReviewMinutes": number}},
+//	    "Durations": {"Easy" | "Medium" | "Hard": {"CodingMinutes": number, "ReviewMinutes": number}},
+//	    "Start": string,  // like "2026-10-20T09:00" or "2026-10-20T09:00:00+02:00", right away if left out
+//	    "End": string,  // when the final results are frozen, no end if left out
+//	    "TimeZone": string  // like "Europe/Paris", of "Start" and "End" when they have no offset
+//	}
+//
+// A problem's phases last as long as its override says, then as long as its
+// problem file says, then as long as the "Durations" of its difficulty say.
+var scheduleOrders = map[string]model.ScheduleOrder{
+	"file":       model.FileOrder,
+	"playlist":   model.PlaylistOrder,
+	"difficulty": model.DifficultyOrder,
+	"random":     model.RandomOrder,
+}
+
+var exhaustedActions = map[string]model.ExhaustedAction{
+	"loop":      model.LoopSchedule,
+	"stop":      model.StopSchedule,
+	"reshuffle": model.ReshuffleSchedule,
+}
+
+// contestTimeLayouts are the layouts of "Start" and "End" without a UTC offset.
+var contestTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// json_to_contest_time reads the time of field key, in location unless it has an offset.
+func json_to_contest_time(scheduleJSON map[string]interface{}, key string, location *time.Location) (time.Time, error) {
+	raw, ok := scheduleJSON[key]
+	if !ok {
+		return time.Time{}, nil
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid schedule: '%s' must be a string", key)
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range contestTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid schedule: invalid time '%s' in '%s', expected a time like \"2026-10-20T09:00\"", value, key)
+}
+
+// json_to_phase_durations reads the optional "CodingMinutes" and "ReviewMinutes" of an object.
+func json_to_phase_durations(value interface{}) (model.PhaseDurations, error) {
+	var durations model.PhaseDurations
+	durationsJSON, ok := value.(map[string]interface{})
+	if !ok {
+		return durations, fmt.Errorf("expected an object of \"CodingMinutes\" and \"ReviewMinutes\"")
+	}
+	for key, dst := range map[string]*float64{"CodingMinutes": &durations.CodingMins, "ReviewMinutes": &durations.ReviewMins} {
+		raw, ok := durationsJSON[key]
+		if !ok {
+			continue
+		}
+		minutes, ok := raw.(float64)
+		if !ok || minutes <= 0 {
+			return durations, fmt.Errorf("'%s' must be a positive number", key)
+		}
+		*dst = minutes
+	}
+	return durations, nil
+}
+
+func json_to_schedule(value interface{}) (model.Schedule, error) {
+	var schedule model.Schedule
+	scheduleJSON, ok := value.(map[string]interface{})
+	if !ok {
+		return schedule, fmt.Errorf("invalid schedule: not an object")
+	}
+
+	if raw, ok := scheduleJSON["Order"]; ok {
+		name, _ := raw.(string)
+		if schedule.Order, ok = scheduleOrders[name]; !ok {
+			return schedule, fmt.Errorf("invalid schedule: invalid field 'Order', valid options are: \"file\", \"playlist\", \"difficulty\", \"random\"")
+		}
+	}
+	if raw, ok := scheduleJSON["WhenExhausted"]; ok {
+		name, _ := raw.(string)
+		if schedule.WhenExhausted, ok = exhaustedActions[name]; !ok {
+			return schedule, fmt.Errorf("invalid schedule: invalid field 'WhenExhausted', valid options are: \"loop\", \"stop\", \"reshuffle\"")
+		}
+	}
+	if raw, ok := scheduleJSON["Seed"]; ok {
+		seed, ok := raw.(float64)
+		if !ok || seed < 0 || seed != float64(uint64(seed)) {
+			return schedule, fmt.Errorf("invalid schedule: 'Seed' must be a non-negative integer")
+		}
+		schedule.Seed = uint64(seed)
+	}
+
+	if raw, ok := scheduleJSON["Playlist"]; ok {
+		playlist, ok := raw.([]interface{})
+		if !ok {
+			return schedule, fmt.Errorf("invalid schedule: 'Playlist' must be an array of problem names")
+		}
+		for _, entry := range playlist {
+			name, ok := entry.(string)
+			if !ok {
+				return schedule, fmt.Errorf("invalid schedule: 'Playlist' must be an array of problem names")
+			}
+			schedule.Playlist = append(schedule.Playlist, name)
+		}
+	}
+	if len(schedule.Playlist) > 0 && schedule.Order != model.PlaylistOrder {
+		return schedule, fmt.Errorf("invalid schedule: 'Playlist' is only used with the \"playlist\" order")
+	}
+
+	if raw, ok := scheduleJSON["Overrides"]; ok {
+		overrides, ok := raw.(map[string]interface{})
+		if !ok {
+			return schedule, fmt.Errorf("invalid schedule: 'Overrides' must be an object of problem names")
+		}
+		schedule.Overrides = make(map[string]model.PhaseDurations)
+		for name, value := range overrides {
+			durations, err := json_to_phase_durations(value)
+			if err != nil {
+				return schedule, fmt.Errorf("invalid schedule: override of '%s': %w", name, err)
+			}
+			schedule.Overrides[name] = durations
+		}
+	}
+
+	if raw, ok := scheduleJSON["Durations"]; ok {
+		durationsJSON, ok := raw.(map[string]interface{})
+		if !ok {
+			return schedule, fmt.Errorf("invalid schedule: 'Durations' must be an object of difficulties")
+		}
+		schedule.DifficultyDurations = make(map[model.ProblemDifficulty]model.PhaseDurations)
+	difficulties:
+		for name, value := range durationsJSON {
+			durations, err := json_to_phase_durations(value)
+			if err != nil {
+				return schedule, fmt.Errorf("invalid schedule: durations of '%s': %w", name, err)
+			}
+			for difficulty, difficultyName := range difficultyNames {
+				if name == difficultyName {
+					schedule.DifficultyDurations[difficulty] = durations
+					continue difficulties
+				}
+			}
+			return schedule, fmt.Errorf("invalid schedule: 'Durations' of unknown difficulty '%s', valid options are: \"Easy\", \"Medium\", \"Hard\"", name)
+		}
+	}
+
+	schedule.Location = time.Local
+	if raw, ok := scheduleJSON["TimeZone"]; ok {
+		name, _ := raw.(string)
+		location, err := time.LoadLocation(name)
+		if err != nil || name == "" {
+			return schedule, fmt.Errorf("invalid schedule: unknown 'TimeZone' '%s'", name)
+		}
+		schedule.Location = location
+	}
+	var err error
+	if schedule.Start, err = json_to_contest_time(scheduleJSON, "Start", schedule.Location); err != nil {
+		return schedule, err
+	}
+	if schedule.End, err = json_to_contest_time(scheduleJSON, "End", schedule.Location); err != nil {
+		return schedule, err
+	}
+	return schedule, nil
+}
+
+// InitSchedule starts the schedule of the file at path in the selected room,
+// or plays its problems in file order, looping, if there is no such file.
+// Call after InitProblems.
+func InitSchedule(path string) error {
+	var schedule model.Schedule
+	bytes, err := os.ReadFile(path)
+	if err == nil {
+		var value interface{}
+		if err = json.Unmarshal(bytes, &value); err != nil {
+			return fmt.Errorf("invalid schedule: bad JSON structure")
+		}
+		if schedule, err = json_to_schedule(value); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err = model.SetSchedule(schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	return nil
+}
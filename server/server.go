@@ -3,13 +3,19 @@ package server
 // Importing packages
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"server/api"
+	"server/i18n"
 	"server/model"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -19,31 +25,80 @@ import (
 var server *http.Server
 var serverMutex sync.Mutex
 
-func InitProblems(path string) error {
-	return parse_problems(path)
+// InitProblems loads the problems in the directory at path, or the ones in
+// defaults if there is no such directory, into the selected room.
+func InitProblems(path string, defaults fs.FS) error {
+	fsys := defaults
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		fsys = os.DirFS(path)
+	} else {
+		fmt.Println("No", path, "directory, using the built-in problems.")
+	}
+
+	problems, err := parse_problems(fsys, false)
+	if err != nil {
+		return err
+	}
+	model.ProblemList = problems
+	return nil
 }
 
 func Init(port uint16) error {
 	serverMutex.Lock()
 	defer serverMutex.Unlock()
 
+	// the routes of a room, served for the default room as they are and for
+	// every room under /api/rooms/{room}/
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/challenge", api.RouteGET_CurrentChallenge)
+	mux.HandleFunc("/api/sample_file", api.RouteGET_SampleFile)
+	mux.HandleFunc("/api/statement_image", api.RouteGET_StatementImage)
 	mux.HandleFunc("/api/check_solution", api.RoutePOST_CheckSolution)
 	mux.HandleFunc("/api/submit", api.RoutePOST_Submit)
+	mux.HandleFunc("/api/toolchains", api.RouteGET_Toolchains)
 	mux.HandleFunc("/api/join", api.RoutePOST_JoinUser)
+	mux.HandleFunc("/api/set_language", api.RoutePOST_SetLanguage)
 	mux.HandleFunc("/api/get_users", api.RoutePOST_GetUsers)
 	mux.HandleFunc("/api/get_submissions", api.RoutePOST_GetSubmissions)
 	mux.HandleFunc("/api/get_code_reviews", api.RouteGET_GetCodeReviews)
+	mux.HandleFunc("/api/get_submission_versions", api.RouteGET_GetSubmissionVersions)
+	mux.HandleFunc("/api/get_submission_version", api.RouteGET_GetSubmissionVersion)
+	mux.HandleFunc("/api/submission_diff", api.RouteGET_SubmissionDiff)
 	mux.HandleFunc("/api/speed_leaderboard", api.RouteGET_SpeedLeaderboard)
-	mux.HandleFunc("/api/quality_leaderboard", api.RoutePOST_GetSubmissions)
+	mux.HandleFunc("/api/quality_leaderboard", api.RouteGET_QualityLeaderboard)
+	mux.HandleFunc("/api/reviewer_reputation", api.RouteGET_ReviewerReputation)
+	mux.HandleFunc("/api/cumulative_leaderboard", api.RouteGET_CumulativeLeaderboard)
 	mux.HandleFunc("/api/get_state", api.RouteGET_GetState)
 	mux.HandleFunc("/api/add_code_review", api.RoutePOST_AddCodeReview)
+	mux.HandleFunc("/api/my_review_assignments", api.RouteGET_MyReviewAssignments)
+	mux.HandleFunc("/api/add_inline_comment", api.RoutePOST_AddInlineComment)
+	mux.HandleFunc("/api/reply_inline_comment", api.RoutePOST_ReplyInlineComment)
+	mux.HandleFunc("/api/edit_code_review", api.RoutePOST_EditCodeReview)
+	mux.HandleFunc("/api/delete_code_review", api.RoutePOST_DeleteCodeReview)
+	mux.HandleFunc("/api/flag_code_review", api.RoutePOST_FlagCodeReview)
+	mux.HandleFunc("/api/rate_code_review", api.RoutePOST_RateCodeReview)
+	mux.HandleFunc("/api/review_quota", api.RouteGET_ReviewQuota)
+	mux.HandleFunc("/api/admin/round_settings", api.RoutePOST_AdminRoundSettings)
+	mux.HandleFunc("/api/admin/moderation_queue", api.RouteGET_AdminModerationQueue)
+	mux.HandleFunc("/api/admin/moderate_review", api.RoutePOST_AdminModerateReview)
+	mux.HandleFunc("/api/admin/similarity_report", api.RouteGET_AdminSimilarityReport)
+	mux.HandleFunc("/api/admin/select_submission_version", api.RoutePOST_AdminSelectSubmissionVersion)
+	mux.HandleFunc("/api/admin/reload_status", api.RouteGET_AdminReloadStatus)
+	mux.HandleFunc("/api/admin/apply_errata", api.RoutePOST_AdminApplyErrata)
+	mux.HandleFunc("/api/admin/schedule", api.RouteGET_AdminSchedule)
+	mux.HandleFunc("/api/errata", api.RouteGET_Errata)
+	mux.HandleFunc("/api/notifications", api.RouteGET_Notifications)
 	mux.HandleFunc("/api/get_time_left", api.RoutePOST_GetCycleTimeLeft)
+	mux.HandleFunc("/api/timeline", api.RouteGET_Timeline)
+
+	rootMux := http.NewServeMux()
+	rootMux.HandleFunc("/api/rooms", api.RouteGET_Rooms)
+	rootMux.Handle("/api/rooms/{room}/", api.RoomRoutes(mux))
+	rootMux.Handle("/", mux)
 
 	server = &http.Server{
 		Addr:    ":" + strconv.Itoa(int(port)),
-		Handler: mux,
+		Handler: rootMux,
 	}
 
 	go func() {
@@ -67,7 +122,8 @@ func Terminate() {
 	}
 }
 
-func json_to_problem(value interface{}, itr uint32) (*model.Problem, error) {
+// json_to_problem reads a problem, external test data is looked up in dataFS.
+func json_to_problem(value interface{}, itr uint32, dataFS fs.FS) (*model.Problem, error) {
 	problemJSON, ok := value.(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid problem in problems list; problem is not an object.")
@@ -88,6 +144,43 @@ func json_to_problem(value interface{}, itr uint32) (*model.Problem, error) {
 		return nil, fmt.Errorf("invalid problem: missing or invalid 'Difficulty'")
 	}
 
+	// get objective and statement, optional
+	objectiveStr, _ := problemJSON["Objective"].(string)
+	sourceStr, _ := problemJSON["Source"].(string)
+	var statement string
+	if statementJSON, ok := problemJSON["Statement"]; ok {
+		var err error
+		if statement, err = json_to_statement(statementJSON); err != nil {
+			return nil, err
+		}
+	}
+
+	// get statement language and translations, optional
+	language := i18n.DefaultLanguage
+	if languageJSON, ok := problemJSON["Language"]; ok {
+		language, _ = languageJSON.(string)
+		if err := model.ValidateLanguageTag(language); err != nil {
+			return nil, fmt.Errorf("invalid problem: invalid field 'Language': %w", err)
+		}
+	}
+	translations := make(map[string]model.Translation)
+	if statementsJSON, ok := problemJSON["Statements"]; ok {
+		statements, ok := statementsJSON.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid problem: 'Statements' must be an object of statements by language")
+		}
+		for translationLanguage, value := range statements {
+			if err := model.ValidateLanguageTag(translationLanguage); err != nil {
+				return nil, fmt.Errorf("invalid problem: invalid language in 'Statements': %w", err)
+			}
+			translated, err := json_to_statement(value)
+			if err != nil {
+				return nil, err
+			}
+			translations[translationLanguage] = model.Translation{Statement: translated}
+		}
+	}
+
 	var diff model.ProblemDifficulty
 	if diffStr == "Easy" {
 		diff = model.Easy
@@ -112,29 +205,128 @@ func json_to_problem(value interface{}, itr uint32) (*model.Problem, error) {
 		}
 		testCase.CaseSensitive = caseSensitive
 
+		// get mode, optional
+		if modeStr, ok := testCaseJSON["Mode"]; ok {
+			if modeStr == "text" {
+				testCase.Mode = model.TextMode
+			} else if modeStr != "json" {
+				return nil, fmt.Errorf("invalid problem: invalid field 'Mode', valid options are: \"json\", \"text\"")
+			}
+		}
+		// get data files, optional
+		if err := json_to_test_data_files(testCaseJSON, &testCase, dataFS); err != nil {
+			return nil, err
+		}
+
+		if testCase.Mode == model.TextMode {
+			if err := json_to_text_test_case(testCaseJSON, &testCase); err != nil {
+				return nil, err
+			}
+			testCases = append(testCases, testCase)
+			continue
+		}
+
 		// get input
-		inputJSONmap, ok := testCaseJSON["Input"].(map[string]interface{})
+		if testCase.InputFile == nil {
+			inputJSONmap, ok := testCaseJSON["Input"].(map[string]interface{})
+
+			// Marshal the map into a JSON byte slice
+			jsonBytes, err := json.Marshal(inputJSONmap)
+			if err != nil {
+				return nil, fmt.Errorf("invalid input JSON structure")
+			}
+
+			testCase.Input = string(jsonBytes)
+
+			if !ok || testCase.Input == "" {
+				return nil, fmt.Errorf("invalid problem: missing or invalid field 'Input'")
+			}
+		}
+
+		// get output
+		if testCase.OutputFile == nil {
+			outputStr, ok := testCaseJSON["Output"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid problem: missing or invalid field 'Output'")
+			}
+			testCase.OutputJSON = outputStr
+		}
+
+		testCases = append(testCases, testCase)
+	}
 
-		// Marshal the map into a JSON byte slice
-		jsonBytes, err := json.Marshal(inputJSONmap)
+	// get rubric, optional
+	var rubric []model.RubricCriterion
+	if rubricJSON, ok := problemJSON["Rubric"]; ok {
+		jsonBytes, err := json.Marshal(rubricJSON)
+		if err != nil {
+			return nil, fmt.Errorf("invalid problem: invalid field 'Rubric'")
+		}
+		err = json.Unmarshal(jsonBytes, &rubric)
+		if err != nil {
+			return nil, fmt.Errorf("invalid problem: 'Rubric' must be an array of {'Name': string, 'Scale': integer, 'Weight': number}")
+		}
+		err = model.ValidateRubric(rubric)
 		if err != nil {
-			return nil, fmt.Errorf("invalid input JSON structure")
+			return nil, fmt.Errorf("invalid problem: %w", err)
 		}
+	}
 
-		testCase.Input = string(jsonBytes)
+	// get allowed extensions, optional
+	var extensions []string
+	if extensionsJSON, ok := problemJSON["AllowedExtensions"]; ok {
+		list, ok := extensionsJSON.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid problem: 'AllowedExtensions' must be an array of strings")
+		}
+		for _, value := range list {
+			ext, ok := value.(string)
+			if !ok || !strings.HasPrefix(ext, ".") {
+				return nil, fmt.Errorf("invalid problem: 'AllowedExtensions' must be an array of strings like \".c\"")
+			}
+			extensions = append(extensions, ext)
+		}
+	}
 
-		if !ok || testCase.Input == "" {
-			return nil, fmt.Errorf("invalid problem: missing or invalid field 'Input'")
+	// get manifest rules, optional
+	var manifestRules *model.ManifestRules
+	if manifestJSON, ok := problemJSON["Manifest"]; ok {
+		rules := model.DefaultManifestRules
+		jsonBytes, err := json.Marshal(manifestJSON)
+		if err == nil {
+			err = json.Unmarshal(jsonBytes, &rules)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid problem: 'Manifest' must be an object: {'Required': bool, 'Languages': [string], 'AllowCustomBuild': bool, 'AllowCustomRun': bool}")
 		}
+		for _, language := range rules.Languages {
+			if _, ok := model.Toolchains[language]; !ok {
+				return nil, fmt.Errorf("invalid problem: unknown language '%s' in 'Manifest'", language)
+			}
+		}
+		manifestRules = &rules
+	}
 
-		// get output
-		outputStr, ok := testCaseJSON["Output"].(map[string]interface{})
+	// get starter templates, optional
+	var starters []model.StarterTemplate
+	if startersJSON, ok := problemJSON["Starters"]; ok {
+		list, ok := startersJSON.([]interface{})
 		if !ok {
-			return nil, fmt.Errorf("invalid problem: missing or invalid field 'Output'")
+			return nil, fmt.Errorf("invalid problem: 'Starters' must be an array")
+		}
+		for _, value := range list {
+			starter, err := json_to_starter(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid problem '%s': %w", nameStr, err)
+			}
+			starters = append(starters, *starter)
 		}
-		testCase.OutputJSON = outputStr
+	}
 
-		testCases = append(testCases, testCase)
+	// get phase durations, optional
+	durations, err := json_to_phase_durations(problemJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid problem '%s': %w", nameStr, err)
 	}
 
 	problem := &model.Problem{
@@ -144,62 +336,292 @@ func json_to_problem(value interface{}, itr uint32) (*model.Problem, error) {
 		},
 		Difficulty: diff,
 		Id:         uint16(itr),
+		Objective:  objectiveStr,
+		Source:     sourceStr,
 		TestCases:  testCases,
+		Rubric:     rubric,
+		Durations:  durations,
+
+		Statement:    statement,
+		Language:     language,
+		Translations: translations,
+
+		AllowedExtensions: extensions,
+		Manifest:          manifestRules,
+		Starters:          starters,
 	}
 
 	return problem, nil
 }
 
-func parse_problem_file(path string) error {
-	jsonFile, err := os.Open(path)
+// json_to_text_string reads a string that may also be given as an array of
+// lines, which are joined with a newline after each.
+func json_to_text_string(value interface{}) (string, bool) {
+	switch text := value.(type) {
+	case string:
+		return text, true
+	case []interface{}:
+		var lines []string
+		for _, line := range text {
+			lineStr, ok := line.(string)
+			if !ok {
+				return "", false
+			}
+			lines = append(lines, lineStr)
+		}
+		return strings.Join(lines, "\n") + "\n", true
+	}
+	return "", false
+}
+
+// json_to_text_test_case reads the input, output and comparison of a test case in text mode.
+func json_to_text_test_case(testCaseJSON map[string]interface{}, testCase *model.TestCase) error {
+	var ok bool
+	if testCase.InputFile == nil {
+		testCase.Input, ok = json_to_text_string(testCaseJSON["Input"])
+		if !ok {
+			return fmt.Errorf("invalid problem: missing or invalid field 'Input', text test cases expect a string or an array of lines")
+		}
+	}
+	if testCase.OutputFile == nil {
+		testCase.OutputText, ok = json_to_text_string(testCaseJSON["Output"])
+		if !ok {
+			return fmt.Errorf("invalid problem: missing or invalid field 'Output', text test cases expect a string or an array of lines")
+		}
+	}
+
+	switch testCaseJSON["Comparison"] {
+	case nil, "whitespace":
+		testCase.Comparison = model.WhitespaceComparison
+	case "exact":
+		testCase.Comparison = model.ExactComparison
+	case "tokens":
+		testCase.Comparison = model.TokenComparison
+	default:
+		return fmt.Errorf("invalid problem: invalid field 'Comparison', valid options are: \"whitespace\", \"exact\", \"tokens\"")
+	}
+	return nil
+}
+
+// json_to_test_data_file reads a reference to a test data file, an object
+// {"Path": string, "SHA256": string} with a path relative to the problem file.
+// The file is only checked to exist, it is read when first needed.
+func json_to_test_data_file(value interface{}, field string, dataFS fs.FS) (*model.TestDataFile, error) {
+	fileJSON, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid problem: '%s' must be an object: {'Path': string, 'SHA256': string}", field)
+	}
+	filePath, ok := fileJSON["Path"].(string)
+	if !ok || !fs.ValidPath(filePath) || filePath == "." {
+		return nil, fmt.Errorf("invalid problem: missing or invalid 'Path' in '%s', expects a path inside the problem's directory", field)
+	}
+	hash, ok := fileJSON["SHA256"].(string)
+	if decoded, err := hex.DecodeString(hash); !ok || err != nil || len(decoded) != sha256.Size {
+		return nil, fmt.Errorf("invalid problem: missing or invalid 'SHA256' in '%s'", field)
+	}
+	info, err := fs.Stat(dataFS, filePath)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("invalid problem: test data '%s' not found", filePath)
+	}
+	return &model.TestDataFile{FS: dataFS, Path: filePath, SHA256: hash}, nil
+}
+
+// json_to_test_data_files reads a test case's optional 'InputFile', 'OutputFile', 'Sample' and 'Name'.
+func json_to_test_data_files(testCaseJSON map[string]interface{}, testCase *model.TestCase, dataFS fs.FS) error {
+	var err error
+	if value, ok := testCaseJSON["InputFile"]; ok {
+		if testCase.InputFile, err = json_to_test_data_file(value, "InputFile", dataFS); err != nil {
+			return err
+		}
+	}
+	if value, ok := testCaseJSON["OutputFile"]; ok {
+		if testCase.OutputFile, err = json_to_test_data_file(value, "OutputFile", dataFS); err != nil {
+			return err
+		}
+	}
+	testCase.External = testCase.InputFile != nil || testCase.OutputFile != nil
+
+	if value, ok := testCaseJSON["Sample"]; ok {
+		if testCase.Sample, ok = value.(bool); !ok {
+			return fmt.Errorf("invalid problem: invalid field 'Sample'")
+		}
+	}
+	if value, ok := testCaseJSON["Name"]; ok {
+		if testCase.Name, ok = value.(string); !ok || !fs.ValidPath(testCase.Name) || testCase.Name == "." {
+			return fmt.Errorf("invalid problem: invalid field 'Name', expected a relative path like \"secret/group1/big\"")
+		}
+	}
+	return nil
+}
+
+// json_to_starter reads a starter template and checks that it compiles. The
+// code may be a string or an array of lines.
+func json_to_starter(value interface{}) (*model.StarterTemplate, error) {
+	starterJSON, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("starter template is not an object")
+	}
+
+	var starter model.StarterTemplate
+	starter.Language, ok = starterJSON["Language"].(string)
+	if _, known := model.Toolchains[starter.Language]; !ok || !known {
+		return nil, fmt.Errorf("starter template has a missing or unknown 'Language'")
+	}
+	starter.FileName, ok = starterJSON["FileName"].(string)
+	if !ok || model.ValidateFileName(starter.FileName) != nil {
+		return nil, fmt.Errorf("%s starter template has a missing or invalid 'FileName'", starter.Language)
+	}
+
+	starter.Code, ok = json_to_text_string(starterJSON["Code"])
+	if !ok {
+		return nil, fmt.Errorf("%s starter template: 'Code' must be a string or an array of strings", starter.Language)
+	}
+
+	if err := check_starter(starter); err != nil {
+		return nil, err
+	}
+	return &starter, nil
+}
+
+// check_starter fails if a starter template doesn't compile. It only warns if
+// the language's compiler isn't installed.
+func check_starter(starter model.StarterTemplate) error {
+	err := model.CheckCompiles(starter.Language, starter.FileName, starter.Code)
+	if errors.Is(err, model.ErrToolchainUnavailable) {
+		fmt.Println("Warning: could not check the", starter.Language, "starter template:", err)
+	} else if err != nil {
+		return fmt.Errorf("%s starter template does not compile: %w", starter.Language, err)
+	}
+	return nil
+}
+
+// parse_problem_file reads a JSON file listing problems, test data paths are
+// relative to the file's directory in fsys.
+func parse_problem_file(fsys fs.FS, name string) ([]model.Problem, error) {
+	bytes, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		fmt.Println("Failed to open JSON problems file! err: ", err)
-		return err
+		return nil, err
+	}
+	dataFS, err := fs.Sub(fsys, path.Dir(name))
+	if err != nil {
+		return nil, err
 	}
-	defer jsonFile.Close()
-
-	bytes, _ := io.ReadAll(jsonFile)
 
 	var result map[string]interface{}
 	err = json.Unmarshal([]byte(bytes), &result)
 	if err != nil {
-		return fmt.Errorf("invalid problems file:  bad JSON structure")
+		return nil, fmt.Errorf("invalid problems file:  bad JSON structure")
 	}
 
-	_, ok := result["Problems"]
+	problemsMap, ok := result["Problems"].([]interface{})
 	if !ok {
-		return fmt.Errorf("invalid problems file:  bad JSON structure")
+		return nil, fmt.Errorf("invalid problems file:  bad JSON structure")
 	}
-	problemsMap := result["Problems"].([]interface{})
 
+	var problems []model.Problem
 	var itr uint32 = 0
 	for _, value := range problemsMap {
 		var problem *model.Problem
-		problem, err = json_to_problem(value, itr)
+		problem, err = json_to_problem(value, itr, dataFS)
+		if err == nil {
+			err = load_statement(problem, dataFS)
+		}
 
 		if err != nil {
-			return err
+			return nil, err
 		}
-		model.ProblemList = append(model.ProblemList, *problem)
+		problem.Revision = problem_revision(value, problem)
+		problems = append(problems, *problem)
 
 		itr++
 	}
+	return problems, nil
+}
+
+// problem_revision hashes a problem's JSON and the images of its statement.
+// Test data files are covered by the hashes in the JSON.
+func problem_revision(value interface{}, problem *model.Problem) string {
+	hash := sha256.New()
+	jsonBytes, _ := json.Marshal(value) // map keys are sorted, so this is stable
+	hash.Write(jsonBytes)
+	var sources []string
+	for src := range problem.Images {
+		sources = append(sources, src)
+	}
+	slices.Sort(sources)
+	for _, src := range sources {
+		fmt.Fprintf(hash, "\x00%s\x00%d\x00", src, len(problem.Images[src]))
+		hash.Write(problem.Images[src])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// check_problem_names rejects the problems loaded from file if one of them
+// has the name of a problem already loaded, and records their names otherwise.
+func check_problem_names(loaded []model.Problem, file string, loadedFrom map[string]string) error {
+	names := make(map[string]bool)
+	for _, problem := range loaded {
+		name := problem.Header.Name
+		if other, ok := loadedFrom[name]; ok {
+			return fmt.Errorf("a problem named '%s' is already loaded from %s", name, other)
+		}
+		if names[name] {
+			return fmt.Errorf("more than one problem named '%s'", name)
+		}
+		names[name] = true
+	}
+	for name := range names {
+		loadedFrom[name] = file
+	}
 	return nil
 }
 
-func parse_problems(dir string) error {
-	entries, err := os.ReadDir(dir)
+// parse_problems loads the problem files and packs at the root of fsys:
+// JSON problem lists, pack directories and zipped packs. Files that fail to
+// load are skipped, unless strict is set, then the first failure is returned.
+func parse_problems(fsys fs.FS, strict bool) ([]model.Problem, error) {
+	entries, err := fs.ReadDir(fsys, ".")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var problems []model.Problem
+	// problems are looked up by name, in the schedule among others
+	loadedFrom := make(map[string]string)
 	fmt.Println("Loaded problems:")
 	for _, e := range entries {
-		if !e.IsDir() {
-			parse_problem_file(dir + "/" + e.Name())
+		name := e.Name()
+		var loaded []model.Problem
+		if e.IsDir() {
+			// other directories hold test data
+			if _, err := fs.Stat(fsys, path.Join(name, PackProblemFile)); err != nil {
+				continue
+			}
+			loaded, err = parse_pack_dir(fsys, name)
+		} else if strings.HasSuffix(strings.ToLower(name), ".zip") {
+			loaded, err = parse_pack_zip(fsys, name)
+		} else {
+			loaded, err = parse_problem_file(fsys, name)
+		}
+		if err == nil {
+			err = check_problem_names(loaded, name, loadedFrom)
+		}
+
+		if err != nil {
+			if strict {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			fmt.Println("Failed to load", name+":", err)
+			continue
 		}
-		fmt.Println("\t", strings.Split(e.Name(), ".")[0])
+		problems = append(problems, loaded...)
+		fmt.Println("\t", strings.Split(name, ".")[0])
 	}
 
-	return nil
+	for i := range problems {
+		problems[i].Id = uint16(i)
+		problems[i].Version = 1
+	}
+	return problems, nil
 }
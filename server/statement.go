@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"server/api"
+	"server/markdown"
+	"server/model"
+	"slices"
+	"strings"
+)
+
+// statementImageTypes are the image files a statement may embed. SVG is left
+// out as it can carry scripts.
+var statementImageTypes = []string{".png", ".jpg", ".jpeg", ".gif", ".webp"}
+
+// statementSections are the sections of a statement given as an object, in
+// the order they are rendered.
+var statementSections = []struct{ key, heading string }{
+	{"Description", ""},
+	{"Input", "Input"},
+	{"Output", "Output"},
+	{"Constraints", "Constraints"},
+	{"Examples", "Examples"},
+	{"Notes", "Notes"},
+}
+
+// json_to_statement reads a statement: Markdown as a string or an array of
+// lines, or an object of sections which are joined under their headings.
+// Examples are an array of {'Input': string, 'Output': string, 'Explanation': string}.
+func json_to_statement(value interface{}) (string, error) {
+	if text, ok := json_to_text_string(value); ok {
+		return text, nil
+	}
+	sectionsJSON, ok := value.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("invalid problem: 'Statement' must be a string, an array of lines or an object of sections")
+	}
+
+	var statement strings.Builder
+	for _, section := range statementSections {
+		value, ok := sectionsJSON[section.key]
+		if !ok {
+			continue
+		}
+		if section.heading != "" {
+			statement.WriteString("## " + section.heading + "\n\n")
+		}
+
+		if section.key == "Examples" {
+			examples, ok := value.([]interface{})
+			if !ok {
+				return "", fmt.Errorf("invalid problem: 'Statement' 'Examples' must be an array")
+			}
+			for i, exampleValue := range examples {
+				example, _ := exampleValue.(map[string]interface{})
+				input, inputOk := json_to_text_string(example["Input"])
+				output, outputOk := json_to_text_string(example["Output"])
+				if !inputOk || !outputOk {
+					return "", fmt.Errorf("invalid problem: 'Statement' example %d must have an 'Input' and an 'Output'", i+1)
+				}
+				fmt.Fprintf(&statement, "### Example %d\n\nInput:\n\n```\n%s\n```\n\nOutput:\n\n```\n%s\n```\n\n",
+					i+1, strings.TrimRight(input, "\n"), strings.TrimRight(output, "\n"))
+				if explanation, ok := json_to_text_string(example["Explanation"]); ok {
+					statement.WriteString(strings.TrimRight(explanation, "\n") + "\n\n")
+				}
+			}
+			continue
+		}
+
+		text, ok := json_to_text_string(value)
+		if !ok {
+			return "", fmt.Errorf("invalid problem: 'Statement' '%s' must be a string or an array of lines", section.key)
+		}
+		statement.WriteString(strings.TrimRight(text, "\n") + "\n\n")
+	}
+	return statement.String(), nil
+}
+
+// load_statement renders the problem's statement and its translations and
+// loads the images they embed from fsys, where their paths are relative to.
+func load_statement(problem *model.Problem, fsys fs.FS) error {
+	if problem.Statement == "" {
+		problem.Statement = problem.Objective
+	}
+	delete(problem.Translations, problem.Language)
+
+	problem.Images = make(map[string][]byte)
+	if err := load_statement_images(problem, problem.Statement, fsys); err != nil {
+		return err
+	}
+	problem.StatementHTML = render_statement(problem, problem.Statement)
+
+	problem.Languages = []string{problem.Language}
+	for language, translation := range problem.Translations {
+		if err := load_statement_images(problem, translation.Statement, fsys); err != nil {
+			return err
+		}
+		translation.StatementHTML = render_statement(problem, translation.Statement)
+		problem.Translations[language] = translation
+		problem.Languages = append(problem.Languages, language)
+	}
+	slices.Sort(problem.Languages[1:])
+	return nil
+}
+
+// load_statement_images loads the images embedded in statement into problem.Images.
+func load_statement_images(problem *model.Problem, statement string, fsys fs.FS) error {
+	for _, src := range markdown.ImageSources(statement) {
+		if parsed, err := url.Parse(src); err != nil || parsed.Scheme != "" {
+			continue // absolute URLs are linked as-is
+		}
+		name := path.Clean(strings.TrimPrefix(src, "./"))
+		if !fs.ValidPath(name) || !slices.Contains(statementImageTypes, strings.ToLower(path.Ext(name))) {
+			return fmt.Errorf("invalid problem '%s': statement image '%s' must be a .png, .jpg, .gif or .webp file inside the problem's directory", problem.Header.Name, src)
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("invalid problem '%s': statement image '%s' not found", problem.Header.Name, src)
+		}
+		problem.Images[src] = data
+	}
+	return nil
+}
+
+func render_statement(problem *model.Problem, statement string) string {
+	return markdown.ToHTML(statement, func(src string) (string, bool) {
+		if _, ok := problem.Images[src]; !ok {
+			return "", false
+		}
+		return api.StatementImageURL(src), true
+	})
+}